@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// absentCache remembers, per project, the IPs that serve and FIFO imports
+// have already reported as absent from Lair, so that repeated scans of the
+// same targets only report hosts that are newly missing.
+type absentCache struct {
+	mu       sync.Mutex
+	projects map[string]*absentProject
+}
+
+// absentProject is the cache of one project: the hosts of its last export
+// and the IPs reported absent since its hosts last changed.
+type absentProject struct {
+	hosts  map[string]bool
+	absent map[string]bool
+}
+
+func newAbsentCache() *absentCache {
+	return &absentCache{projects: make(map[string]*absentProject)}
+}
+
+// filter removes the IPs already reported absent from project lairPID from
+// notFound, records the rest as reported and returns the number removed. The
+// cache of the project is discarded when its export has a host the previous
+// export did not have, as a host created in Lair may match IPs reported before.
+func (c *absentCache) filter(lairPID string, existing []lair.Host, notFound map[string][]string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.projects[lairPID]
+	if p != nil {
+		for _, host := range existing {
+			if !p.hosts[host.IPv4] {
+				p = nil
+				break
+			}
+		}
+	}
+	if p == nil {
+		p = &absentProject{absent: make(map[string]bool)}
+		c.projects[lairPID] = p
+	}
+	p.hosts = make(map[string]bool, len(existing))
+	for _, host := range existing {
+		p.hosts[host.IPv4] = true
+	}
+	skipped := 0
+	for ip := range notFound {
+		if p.absent[ip] {
+			delete(notFound, ip)
+			skipped++
+			continue
+		}
+		p.absent[ip] = true
+	}
+	return skipped
+}
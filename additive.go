@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/lair-framework/go-lair"
+)
+
+// applyAdditiveOnly discards every change to objects that already exist in
+// Lair with -additive-only: updates to existing hosts, and issues that would
+// be merged into an existing issue with the same plugin ID, or netblocks with
+// the CIDR of an existing one. New hosts, issues, auth interfaces, netblocks
+// and project notes are kept.
+func (im *importer) applyAdditiveOnly(existing *lair.Project) {
+	if len(im.updated) > 0 {
+		logf("Skipped changes to %d existing hosts with -additive-only", len(im.updated))
+	}
+	im.updated = make(map[string]bool)
+	im.existingIPs = make(map[string]lair.Host)
+
+	known := make(map[string]bool)
+	for _, issue := range existing.Issues {
+		for _, id := range issue.PluginIDs {
+			known[id.ID] = true
+		}
+	}
+	issues := im.project.Issues[:0]
+	skipped := 0
+	for _, issue := range im.project.Issues {
+		if known[issue.PluginIDs[0].ID] {
+			skipped++
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	im.project.Issues = issues
+	if skipped > 0 {
+		logf("Skipped %d issues already in the project with -additive-only", skipped)
+	}
+
+	knownCIDRs := make(map[string]bool)
+	for _, netblock := range existing.Netblocks {
+		knownCIDRs[netblock.CIDR] = true
+	}
+	netblocks := im.project.Netblocks[:0]
+	for _, netblock := range im.project.Netblocks {
+		if !knownCIDRs[netblock.CIDR] {
+			netblocks = append(netblocks, netblock)
+		}
+	}
+	im.project.Netblocks = netblocks
+}
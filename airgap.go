@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// restrictDefaultTransport replaces http.DefaultTransport with one that refuses
+// to dial any host other than lairHost. It is a backstop for -airgap so that a
+// feature which slips past the flag checks fails rather than leaking traffic.
+func restrictDefaultTransport(lairHost string) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if host != lairHost {
+			return nil, fmt.Errorf("airgap: refusing connection to %s", addr)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	http.DefaultTransport = transport
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+const artifactsNotePrefix = "drone-bbot: scan artifacts in "
+
+// artifact is a file in a bbot scan directory that holds raw evidence for a
+// host, with its path relative to the scan directory.
+type artifact struct {
+	kind string
+	path string
+}
+
+// artifactKind returns "screenshot" or "response" for files that hold
+// screenshots or stored HTTP responses, or an empty string for other files.
+func artifactKind(path string) string {
+	lower := strings.ToLower(path)
+	switch filepath.Ext(lower) {
+	case ".png", ".jpg", ".jpeg":
+		return "screenshot"
+	case ".txt", ".html", ".htm", ".http", ".json":
+		for _, dir := range strings.Split(filepath.Dir(lower), "/") {
+			if dir == "httpx" || strings.Contains(dir, "response") {
+				return "response"
+			}
+		}
+	}
+	return ""
+}
+
+// scanArtifacts lists the screenshots and stored HTTP responses in dir.
+func scanArtifacts(dir string) ([]artifact, error) {
+	artifacts := []artifact{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if kind := artifactKind(rel); kind != "" {
+			artifacts = append(artifacts, artifact{kind: kind, path: rel})
+		}
+		return nil
+	})
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].path < artifacts[j].path })
+	return artifacts, err
+}
+
+// namesFile reports whether the file name of path contains name as a whole
+// hostname or IP, rather than as part of a longer one. bbot modules build file
+// names from URLs, so names are usually followed by a dash, underscore or dot.
+func namesFile(path, name string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	name = strings.ToLower(name)
+	if name == "" {
+		return false
+	}
+	for offset := 0; ; {
+		i := strings.Index(base[offset:], name)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(name)
+		before := start == 0 || !isAlphanumeric(base[start-1]) && base[start-1] != '.'
+		after := end == len(base) || !isAlphanumeric(base[end])
+		if before && after {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// isAlphanumeric reports whether b is a lower case letter or a digit.
+func isAlphanumeric(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
+
+// applyArtifacts adds a note to each host that has artifacts in the
+// -scan-dir, listing their paths relative to it. The title names the scan
+// directory so that every scan gets its own note.
+func (im *importer) applyArtifacts(artifacts []artifact) {
+	ips := []string{}
+	for ip := range im.existingIPs {
+		ips = append(ips, ip)
+	}
+	ips = append(ips, uniqueIPs(im.project.Hosts)...)
+	title := artifactsNotePrefix + filepath.Base(filepath.Clean(im.opts.scanDir))
+	for _, ip := range ips {
+		im.updateHost(ip, func(host *lair.Host) bool {
+			if hasNote(host.Notes, title) {
+				return false
+			}
+			names := append([]string{host.IPv4}, host.Hostnames...)
+			var b strings.Builder
+			for _, a := range artifacts {
+				for _, name := range names {
+					if namesFile(a.path, name) {
+						fmt.Fprintf(&b, "%s: %s\n", a.kind, a.path)
+						break
+					}
+				}
+			}
+			if b.Len() == 0 {
+				return false
+			}
+			host.Notes = append(host.Notes, lair.Note{
+				Title:          title,
+				Content:        sanitizeText(fmt.Sprintf("Raw evidence from the bbot scan, paths are relative to %s:\n\n%s", im.opts.scanDir, b.String())),
+				LastModifiedBy: lastModifiedBy,
+			})
+			return true
+		})
+	}
+}
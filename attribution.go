@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// eventLineage is what is kept of each event to attribute the web events
+// discovered from it to specific IPs.
+type eventLineage struct {
+	parent string
+	host   string
+	ips    []string
+}
+
+// recordLineage remembers the parent of an event, its host and the IPs it
+// names: the IP of an event whose host is an IP, or the resolution of a
+// DNS_NAME event.
+func (im *importer) recordLineage(entry map[string]interface{}) {
+	id, _ := entry["id"].(string)
+	if id == "" {
+		return
+	}
+	host, _ := entry["host"].(string)
+	host = strings.ToLower(host)
+	l := eventLineage{parent: eventParent(entry), host: host}
+	if net.ParseIP(host) != nil {
+		l.ips = []string{host}
+	} else if entry["type"] == "DNS_NAME" {
+		l.ips = resolvedHosts(entry)
+	}
+	im.lineage[id] = l
+}
+
+// webIPs returns the IPs a URL, HTTP_RESPONSE or other web event is attributed
+// to. bbot reports every IP the host name of a URL resolves to, so the
+// ancestors of the event are followed for as long as they have the same host
+// name or an IP as host. The first ancestor with an IP as host attributes the
+// event to that IP alone, and the first DNS_NAME ancestor to the IPs it
+// resolved to in this scan. Events without such ancestors keep the IPs of
+// eventIPs.
+func (im *importer) webIPs(entry map[string]interface{}) []string {
+	ips := eventIPs(entry)
+	host, _ := entry["host"].(string)
+	host = strings.ToLower(host)
+	if host == "" || net.ParseIP(host) != nil {
+		return ips
+	}
+	seen := map[string]bool{}
+	for parent := eventParent(entry); parent != "" && !seen[parent] && len(seen) < provenanceMaxDepth; {
+		seen[parent] = true
+		l, found := im.lineage[parent]
+		if !found {
+			break
+		}
+		switch {
+		case net.ParseIP(l.host) != nil:
+			if len(ips) == 0 || len(intersectIPs(ips, l.ips)) > 0 {
+				return l.ips
+			}
+			return ips
+		case l.host != host:
+			return ips
+		case len(l.ips) > 0:
+			if len(ips) == 0 {
+				return l.ips
+			}
+			if narrowed := intersectIPs(ips, l.ips); len(narrowed) > 0 {
+				return narrowed
+			}
+			return ips
+		}
+		parent = l.parent
+	}
+	return ips
+}
+
+// intersectIPs returns the IPs of a that are also in b, in the order of a.
+func intersectIPs(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, ip := range b {
+		in[ip] = true
+	}
+	both := []string{}
+	for _, ip := range a {
+		if in[ip] {
+			both = append(both, ip)
+		}
+	}
+	return both
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// authPathRules map URL path fragments of well known login portals to the
+// kind of auth interface they indicate. Rules are checked in order.
+var authPathRules = []struct {
+	fragment string
+	kind     string
+}{
+	{"/owa", "OWA"},
+	{"/ecp", "Exchange Control Panel"},
+	{"/autodiscover", "Exchange Autodiscover"},
+	{"/adfs/ls", "ADFS"},
+	{"/+cscoe+/", "VPN"},
+	{"/dana-na/", "VPN"},
+	{"/remote/login", "VPN"},
+	{"/global-protect/", "VPN"},
+	{"/vpn", "VPN"},
+	{"/citrix/", "Citrix Gateway"},
+	{"/auth/realms/", "SSO"},
+	{"/saml", "SSO"},
+	{"/sso", "SSO"},
+	{"/oauth", "SSO"},
+	{"/wp-login.php", "Admin panel"},
+	{"/wp-admin", "Admin panel"},
+	{"/administrator", "Admin panel"},
+	{"/manager/html", "Admin panel"},
+	{"/phpmyadmin", "Admin panel"},
+	{"/admin", "Admin panel"},
+	{"/login", "Login page"},
+	{"/signin", "Login page"},
+	{"/logon", "Login page"},
+}
+
+// authTechnologyRules map lower case technology names reported by bbot to the
+// kind of auth interface they indicate.
+var authTechnologyRules = []struct {
+	fragment string
+	kind     string
+}{
+	{"outlook web", "OWA"},
+	{"microsoft exchange", "OWA"},
+	{"adfs", "ADFS"},
+	{"globalprotect", "VPN"},
+	{"pulse secure", "VPN"},
+	{"fortigate", "VPN"},
+	{"cisco asa", "VPN"},
+	{"anyconnect", "VPN"},
+	{"citrix", "Citrix Gateway"},
+	{"okta", "SSO"},
+	{"keycloak", "SSO"},
+	{"shibboleth", "SSO"},
+	{"phpmyadmin", "Admin panel"},
+	{"tomcat manager", "Admin panel"},
+	{"jenkins", "Admin panel"},
+}
+
+// authKindForURL returns the kind of auth interface rawURL points to, or an
+// empty string if it does not look like a login portal.
+func authKindForURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	p := strings.ToLower(u.Path)
+	for _, rule := range authPathRules {
+		if strings.HasPrefix(p, rule.fragment) || strings.Contains(p, rule.fragment+"/") {
+			return rule.kind
+		}
+	}
+	return ""
+}
+
+// authKindForTechnology returns the kind of auth interface a technology
+// indicates, or an empty string.
+func authKindForTechnology(technology string) string {
+	technology = strings.ToLower(technology)
+	for _, rule := range authTechnologyRules {
+		if strings.Contains(technology, rule.fragment) {
+			return rule.kind
+		}
+	}
+	return ""
+}
+
+// addAuthInterface records an auth interface for rawURL unless one already
+// exists for it.
+func (im *importer) addAuthInterface(kind, rawURL, module string) {
+	if im.authInterfaces[rawURL] {
+		return
+	}
+	im.authInterfaces[rawURL] = true
+	description := "Discovered by bbot"
+	if module != "" {
+		description += " module " + module
+	}
+	im.project.AuthInterfaces = append(im.project.AuthInterfaces, lair.AuthInterface{
+		Kind:        kind,
+		URL:         sanitizeText(rawURL),
+		Description: description,
+	})
+}
+
+// handleTechnology records the technology for its service, the URL of
+// technologies that indicate a login portal as an auth interface, and with
+// -cpe-notes and -service-tags the CPE and tags of the web service.
+func (im *importer) handleTechnology(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	technology, _ := data["technology"].(string)
+	rawURL, _ := data["url"].(string)
+	im.recordTechnology(entry, technology)
+	if im.opts.cpeNotes {
+		im.recordCPE(entry, technology, rawURL)
+	}
+	im.recordServiceTags(entry, rawURL)
+	if !im.opts.importAuthInterfaces || rawURL == "" {
+		return
+	}
+	if kind := authKindForTechnology(technology); kind != "" {
+		module, _ := entry["module"].(string)
+		im.addAuthInterface(kind, rawURL, module)
+	}
+}
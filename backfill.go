@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// maxNotFoundListSize is the size of the largest file read as a not-found
+// list, larger files are read as bbot output.
+const maxNotFoundListSize = 16 << 20
+
+// readBackfillNames returns the DNS names to backfill keyed by IP, and a
+// description of where they were read from. path may be a not-found list
+// written by an earlier import, or the bbot output that was imported.
+func readBackfillNames(path, format string) (map[string][]string, string, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Size() <= maxNotFoundListSize && format == "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		if notFound, ok := readNotFoundList(data); ok {
+			return notFound, "not-found list", nil
+		}
+	}
+	types := map[string]bool{"DNS_NAME": true}
+	in, err := openInput(path, format, eventFilter{types: types})
+	if err != nil {
+		return nil, "", err
+	}
+	defer in.Close()
+	names := make(map[string][]string)
+	scanner := newEventScanner(in)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 || !mentionsEventType(line, types) {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, "", fmt.Errorf("could not parse bbot JSON: %s", err.Error())
+		}
+		if entry["type"] == "DNS_NAME" {
+			addDNSNames(names, entry, 0)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", fmt.Errorf("could not read bbot JSON: %s", err.Error())
+	}
+	return names, in.format, nil
+}
+
+// readNotFoundList parses the hosts that were not in Lair as drone-bbot
+// reports them: the table it logs, the content of a -not-found-note or the
+// notFound field of a JSON import summary. It reports false for anything
+// else, such as bbot output.
+func readNotFoundList(data []byte) (map[string][]string, bool) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var s struct {
+			NotFound map[string][]string `json:"notFound"`
+		}
+		if json.Unmarshal(trimmed, &s) != nil || len(s.NotFound) == 0 {
+			return nil, false
+		}
+		return s.NotFound, true
+	case bytes.HasPrefix(trimmed, []byte("[")), !utf8.Valid(data):
+		return nil, false
+	}
+	notFound := make(map[string][]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if net.ParseIP(field) == nil {
+				continue
+			}
+			for _, name := range strings.Split(strings.Join(fields[i+1:], ""), ",") {
+				if strings.Contains(name, ".") && net.ParseIP(name) == nil {
+					notFound[field], _ = appendUnique(notFound[field], sanitizeText(name))
+				}
+			}
+			break
+		}
+	}
+	return notFound, len(notFound) > 0
+}
+
+// addDNSNames records the host of a DNS_NAME event under each IP it resolved
+// to, along with the CNAME targets and nested events of its dns_children, the
+// names an import attaches to hosts.
+func addDNSNames(names map[string][]string, entry map[string]interface{}, depth int) {
+	host, _ := entry["host"].(string)
+	if host == "" {
+		return
+	}
+	resolved := resolvedHosts(entry)
+	for _, ip := range resolved {
+		names[ip], _ = appendUnique(names[ip], sanitizeText(host))
+	}
+	if depth >= maxDNSChildDepth {
+		return
+	}
+	for rtype, values := range dnsChildren(entry) {
+		for _, value := range childValues(values) {
+			switch child := value.(type) {
+			case string:
+				name := strings.TrimSuffix(child, ".")
+				if strings.ToUpper(rtype) != "CNAME" || name == "" || net.ParseIP(name) != nil {
+					continue
+				}
+				for _, ip := range resolved {
+					names[ip], _ = appendUnique(names[ip], sanitizeText(name))
+				}
+			case map[string]interface{}:
+				addDNSNames(names, child, depth+1)
+			}
+		}
+	}
+}
+
+// backfillProject attaches names, keyed by IP, to the hosts of project lairPID
+// that exist now, such as hosts a later drone-nmap run created for IPs an
+// earlier import could not find. Only hostnames are imported, and hosts that
+// are locked or removed are left alone. It returns the number of hosts updated
+// and the names whose IPs are still not in Lair.
+func backfillProject(c *client.C, lairPID, source string, names map[string][]string, maxPayload int) (int, map[string][]string, error) {
+	existing, err := c.ExportProject(lairPID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to export project %s: %s", lairPID, err.Error())
+	}
+	project := &lair.Project{
+		ID:   lairPID,
+		Tool: lastModifiedBy,
+		Commands: []lair.Command{
+			{Tool: tool, Command: "backfill " + source},
+		},
+	}
+	remaining := make(map[string][]string, len(names))
+	for ip, hostnames := range names {
+		remaining[ip] = hostnames
+	}
+	for _, host := range existing.Hosts {
+		hostnames, found := remaining[host.IPv4]
+		if !found {
+			continue
+		}
+		delete(remaining, host.IPv4)
+		if isLockedHost(host) || isRemovedHost(host) {
+			continue
+		}
+		if mergeHost(&host, hostnames, nil) {
+			project.Hosts = append(project.Hosts, host)
+		}
+	}
+	if len(project.Hosts) == 0 {
+		return 0, remaining, nil
+	}
+	if err := importProject(c, project, maxPayload, "", ""); err != nil {
+		return 0, nil, fmt.Errorf("unable to import project %s: %s", lairPID, err.Error())
+	}
+	return len(project.Hosts), remaining, nil
+}
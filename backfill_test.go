@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadNotFoundList(t *testing.T) {
+	notFound := map[string][]string{
+		"192.0.2.1":   {"www.example.com", "app.example.com"},
+		"2001:db8::1": {"mail.example.com"},
+	}
+	want := map[string][]string{
+		"192.0.2.1":   {"app.example.com", "www.example.com"},
+		"2001:db8::1": {"mail.example.com"},
+	}
+	tests := []struct {
+		name   string
+		in     string
+		want   map[string][]string
+		wantOK bool
+	}{
+		{"logged table", strings.Join(notFoundTable(notFound), "\n"), want, true},
+		{"logged table with timestamps", "2026/10/01 09:00:00 IP           DNS NAMES\n2026/10/01 09:00:00 192.0.2.1    app.example.com, www.example.com\n2026/10/01 09:00:00 2001:db8::1  mail.example.com\n", want, true},
+		{"not found note", notFoundNote(notFound, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)).Content, want, true},
+		{"import summary", `{"hostsCreated":0,"notFound":{"192.0.2.1":["app.example.com","www.example.com"],"2001:db8::1":["mail.example.com"]}}`, want, true},
+		{"import summary without hosts", `{"hostsCreated":0}`, nil, false},
+		{"bbot ndjson", `{"type":"DNS_NAME","host":"www.example.com","resolved_hosts":["192.0.2.1"]}`, nil, false},
+		{"json array", `[{"type":"DNS_NAME"}]`, nil, false},
+		{"binary", "SQLite format 3\x00\xff\xfe", nil, false},
+		{"ips without names", "192.0.2.1\n192.0.2.2 10.0.0.1\n", map[string][]string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := readNotFoundList([]byte(tt.in))
+			if ok != tt.wantOK {
+				t.Fatalf("readNotFoundList() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readNotFoundList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
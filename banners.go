@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// httpBannerNoteTitle is the title of the note summarizing the HTTP response
+// of a web service. Lair services have no banner field.
+const httpBannerNoteTitle = "drone-bbot: HTTP banner"
+
+// httpBanner is the status, title and server header of a web service's
+// response.
+type httpBanner struct {
+	ip     string
+	port   int
+	scheme string
+	url    string
+	status string
+	title  string
+	server string
+}
+
+// recordHTTPBanner remembers the banner of the web service an HTTP_RESPONSE
+// event came from. The response for the root path is preferred, otherwise the
+// first response seen for the service is kept.
+func (im *importer) recordHTTPBanner(entry map[string]interface{}, data map[string]interface{}, rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	root := u.Path == "" || u.Path == "/"
+	banner := httpBanner{
+		scheme: u.Scheme,
+		url:    rawURL,
+		server: responseHeaders(data)["server"],
+	}
+	banner.title, _ = data["title"].(string)
+	if status, found := data["status_code"]; found {
+		banner.status = fmt.Sprint(status)
+	}
+	banner.port = urlPort(rawURL)
+	for _, ip := range im.webIPs(entry) {
+		key := fmt.Sprintf("%s:%d", ip, banner.port)
+		if existing, found := im.httpBanners[key]; found && (!root || existing.url == rawURL) {
+			continue
+		}
+		b := banner
+		b.ip = ip
+		im.httpBanners[key] = &b
+	}
+}
+
+// content returns the text of the banner note.
+func (b *httpBanner) content() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "URL: %s\n", b.url)
+	if b.status != "" {
+		fmt.Fprintf(&s, "Status: %s\n", b.status)
+	}
+	if b.title != "" {
+		fmt.Fprintf(&s, "Title: %s\n", strings.TrimSpace(b.title))
+	}
+	if b.server != "" {
+		fmt.Fprintf(&s, "Server: %s\n", b.server)
+	}
+	return sanitizeText(s.String())
+}
+
+// applyHTTPBanners adds the banner note to each recorded web service. Hosts
+// that are not in the project are skipped.
+func (im *importer) applyHTTPBanners() {
+	keys := []string{}
+	for key := range im.httpBanners {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b := im.httpBanners[key]
+		im.updateHost(b.ip, func(host *lair.Host) bool {
+			service := ensureService(host, b.port, "tcp", b.scheme)
+			if hasNote(service.Notes, httpBannerNoteTitle) {
+				return false
+			}
+			service.Notes = append(service.Notes, lair.Note{
+				Title:          httpBannerNoteTitle,
+				Content:        im.limitEvidence(b.content()),
+				LastModifiedBy: lastModifiedBy,
+			})
+			return true
+		})
+	}
+}
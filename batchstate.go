@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// Batch statuses recorded in a -batch-state file.
+const (
+	batchPending = "pending"
+	batchSent    = "sent"
+	batchAcked   = "acked"
+	batchFailed  = "failed"
+)
+
+// batch is the record of one part of an import: the number of hosts, issues,
+// auth interfaces, notes, netblocks and people it holds, a digest of their
+// keys and whether Lair has accepted it. The items themselves are not kept,
+// they are rebuilt from the input when the import is resumed.
+type batch struct {
+	Status string `json:"status"`
+	Counts [6]int `json:"counts"`
+	Digest string `json:"digest"`
+}
+
+// batchState is the transaction log of an import sent in several parts. It
+// is kept in the -batch-state file until every batch has been acknowledged,
+// so that a crashed import of the same input can be resumed without sending
+// the parts Lair already accepted. Input is a digest of the events read.
+type batchState struct {
+	Project string  `json:"project"`
+	Input   string  `json:"input"`
+	Batches []batch `json:"batches"`
+}
+
+// newBatchState returns the state of an import of parts, none of them sent.
+func newBatchState(project, input string, parts []*lair.Project) *batchState {
+	state := &batchState{Project: project, Input: input}
+	for _, part := range parts {
+		counts, digest := partIdentity(part)
+		state.Batches = append(state.Batches, batch{Status: batchPending, Counts: counts, Digest: digest})
+	}
+	return state
+}
+
+// readBatchState returns the batch state at path, or nil when there is none.
+func readBatchState(path string) (*batchState, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &batchState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s is not a batch state file: %s", path, err.Error())
+	}
+	return state, nil
+}
+
+// write saves the state to path, replacing the previous state atomically so
+// that a crash never leaves a truncated file behind. An empty path keeps the
+// state in memory only.
+func (s *batchState) write(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// remaining returns the number of batches Lair has not acknowledged.
+func (s *batchState) remaining() int {
+	count := 0
+	for _, b := range s.Batches {
+		if b.Status != batchAcked {
+			count++
+		}
+	}
+	return count
+}
+
+// rebuild divides project, sorted by sortProject, into the parts recorded in
+// the state. It reports false when project does not hold the same number of
+// items as the recorded parts. An acknowledged part whose items are no longer
+// the same is marked pending so that it is sent again, which Lair merges like
+// any other import.
+func (s *batchState) rebuild(project *lair.Project) ([]*lair.Project, bool) {
+	parts := []*lair.Project{}
+	var offsets [6]int
+	for _, b := range s.Batches {
+		part := &lair.Project{ID: project.ID, Tool: project.Tool, Commands: project.Commands}
+		ok := takeItems(&part.Hosts, project.Hosts, &offsets[0], b.Counts[0]) &&
+			takeItems(&part.Issues, project.Issues, &offsets[1], b.Counts[1]) &&
+			takeItems(&part.AuthInterfaces, project.AuthInterfaces, &offsets[2], b.Counts[2]) &&
+			takeItems(&part.Notes, project.Notes, &offsets[3], b.Counts[3]) &&
+			takeItems(&part.Netblocks, project.Netblocks, &offsets[4], b.Counts[4]) &&
+			takeItems(&part.People, project.People, &offsets[5], b.Counts[5])
+		if !ok {
+			return nil, false
+		}
+		parts = append(parts, part)
+	}
+	totals := [6]int{len(project.Hosts), len(project.Issues), len(project.AuthInterfaces), len(project.Notes), len(project.Netblocks), len(project.People)}
+	if offsets != totals {
+		return nil, false
+	}
+	for i, part := range parts {
+		if _, digest := partIdentity(part); s.Batches[i].Status == batchAcked && digest != s.Batches[i].Digest {
+			s.Batches[i].Status = batchPending
+		}
+	}
+	return parts, true
+}
+
+// takeItems appends the n items of items from *offset to *part, and reports
+// false when items has fewer than n left.
+func takeItems[T any](part *[]T, items []T, offset *int, n int) bool {
+	if n < 0 || *offset+n > len(items) {
+		return false
+	}
+	*part = append(*part, items[*offset:*offset+n]...)
+	*offset += n
+	return true
+}
+
+// partIdentity returns the number of each kind of item in part and a digest
+// of their keys, the host IPs, issue titles, auth interface URLs, note
+// titles, netblock CIDRs and people.
+func partIdentity(part *lair.Project) ([6]int, string) {
+	counts := [6]int{len(part.Hosts), len(part.Issues), len(part.AuthInterfaces), len(part.Notes), len(part.Netblocks), len(part.People)}
+	keys := []string{}
+	for _, host := range part.Hosts {
+		keys = append(keys, "host "+host.IPv4)
+	}
+	for _, issue := range part.Issues {
+		keys = append(keys, "issue "+issue.Title)
+	}
+	for _, ai := range part.AuthInterfaces {
+		keys = append(keys, "auth "+ai.URL)
+	}
+	for _, note := range part.Notes {
+		keys = append(keys, "note "+note.Title)
+	}
+	for _, netblock := range part.Netblocks {
+		keys = append(keys, "netblock "+netblock.CIDR)
+	}
+	for _, person := range part.People {
+		keys = append(keys, "person "+personKey(person))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return counts, hex.EncodeToString(sum[:])
+}
+
+// sortProject orders the items of project by their keys, so that the same
+// input is always divided into the same parts.
+func sortProject(project *lair.Project) {
+	sort.SliceStable(project.Hosts, func(i, j int) bool {
+		ki, bi := ipSortKey(project.Hosts[i].IPv4)
+		kj, bj := ipSortKey(project.Hosts[j].IPv4)
+		if ki != kj {
+			return ki < kj
+		}
+		return bytes.Compare(bi, bj) < 0
+	})
+	sort.SliceStable(project.Issues, func(i, j int) bool { return project.Issues[i].Title < project.Issues[j].Title })
+	sort.SliceStable(project.AuthInterfaces, func(i, j int) bool { return project.AuthInterfaces[i].URL < project.AuthInterfaces[j].URL })
+	sort.SliceStable(project.Notes, func(i, j int) bool { return project.Notes[i].Title < project.Notes[j].Title })
+	sort.SliceStable(project.Netblocks, func(i, j int) bool { return project.Netblocks[i].CIDR < project.Netblocks[j].CIDR })
+	sort.SliceStable(project.People, func(i, j int) bool { return personKey(project.People[i]) < personKey(project.People[j]) })
+}
+
+// sendBatches sends the parts Lair has not acknowledged, recording each
+// status change at path. A part that was sent but not acknowledged before a
+// crash is sent again, which Lair merges like any other import. The state
+// file is removed once every part has been acknowledged.
+func sendBatches(c *client.C, state *batchState, parts []*lair.Project, path string) error {
+	for i, part := range parts {
+		b := &state.Batches[i]
+		if b.Status == batchAcked {
+			continue
+		}
+		b.Status = batchSent
+		if err := state.write(path); err != nil {
+			return fmt.Errorf("unable to write batch state: %s", err.Error())
+		}
+		err := sendPart(c, part)
+		if err == nil {
+			b.Status = batchAcked
+		} else {
+			b.Status = batchFailed
+		}
+		if werr := state.write(path); werr != nil {
+			return fmt.Errorf("unable to write batch state: %s", werr.Error())
+		}
+		if err != nil {
+			if path != "" {
+				return fmt.Errorf("part %d of %d: %s, re-run with the same input to retry the %d remaining parts recorded in %s", i+1, len(parts), err.Error(), state.remaining(), path)
+			}
+			return fmt.Errorf("part %d of %d: %s", i+1, len(parts), err.Error())
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
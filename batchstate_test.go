@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// batchProject returns a project of n hosts with one note each, and n issues.
+func batchProject(n int) *lair.Project {
+	project := &lair.Project{ID: "p1", Tool: lastModifiedBy, Commands: []lair.Command{{Tool: tool, Command: "test"}}}
+	for i := 0; i < n; i++ {
+		ip := fmt.Sprintf("192.0.2.%d", i+1)
+		project.Hosts = append(project.Hosts, lair.Host{
+			IPv4:  ip,
+			Notes: []lair.Note{{Title: "note", Content: strings.Repeat("x", 200)}},
+		})
+		project.Issues = append(project.Issues, lair.Issue{Title: fmt.Sprintf("issue %02d", i), Hosts: []lair.IssueHost{{IPv4: ip}}})
+	}
+	return project
+}
+
+// fakeLair is a Lair API server that exports the projects it holds, records
+// the imports it receives and fails the imports whose number is in fail,
+// counting from 1.
+type fakeLair struct {
+	mu       sync.Mutex
+	projects map[string]lair.Project
+	imports  []lair.Project
+	fail     map[int]bool
+}
+
+func (f *fakeLair) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method == http.MethodGet {
+		json.NewEncoder(w).Encode(f.projects[path.Base(r.URL.Path)])
+		return
+	}
+	var project lair.Project
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.imports = append(f.imports, project)
+	if f.fail[len(f.imports)] {
+		http.Error(w, "import failed", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(`{"Status":"Ok"}`))
+}
+
+// newFakeLair starts a fakeLair and returns a client of it.
+func newFakeLair(t *testing.T, fail ...int) (*fakeLair, *client.C) {
+	t.Helper()
+	f := &fakeLair{projects: make(map[string]lair.Project), fail: make(map[int]bool)}
+	for _, n := range fail {
+		f.fail[n] = true
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	c, err := client.New(&client.COptions{User: "user", Password: "pass", Host: u.Host, Scheme: "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, c
+}
+
+func TestSplitProject(t *testing.T) {
+	project := batchProject(10)
+	total := jsonSize(project)
+	tests := []struct {
+		name      string
+		max       int
+		wantParts int
+	}{
+		{"no limit", 0, 1},
+		{"fits", total, 1},
+		{"two parts", total * 2 / 3, 2},
+		{"one item per part", 900, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := splitProject(project, tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != tt.wantParts {
+				t.Fatalf("splitProject() returned %d parts, want %d", len(parts), tt.wantParts)
+			}
+			hosts, issues := 0, 0
+			for _, part := range parts {
+				if len(part.Commands) != 1 || part.ID != project.ID {
+					t.Errorf("part without the project id or command: %+v", part)
+				}
+				if tt.max > 0 && jsonSize(part) > tt.max {
+					t.Errorf("part of %d bytes, want at most %d", jsonSize(part), tt.max)
+				}
+				hosts += len(part.Hosts)
+				issues += len(part.Issues)
+			}
+			if hosts != len(project.Hosts) || issues != len(project.Issues) {
+				t.Errorf("parts hold %d hosts and %d issues, want %d and %d", hosts, issues, len(project.Hosts), len(project.Issues))
+			}
+		})
+	}
+
+	huge := &lair.Project{Hosts: []lair.Host{{IPv4: "192.0.2.1", Notes: []lair.Note{{Content: strings.Repeat("x", mongoDocumentLimit)}}}}}
+	if _, err := splitProject(huge, 0); err == nil {
+		t.Errorf("splitProject() of a host above the MongoDB document limit succeeded")
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	project := batchProject(6)
+	sortProject(project)
+	parts, err := splitProject(project, jsonSize(project)/3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := newBatchState(project.ID, "input", parts)
+	for i := range state.Batches {
+		state.Batches[i].Status = batchAcked
+	}
+
+	t.Run("same project", func(t *testing.T) {
+		rebuilt, ok := state.rebuild(batchProject(6))
+		if !ok || len(rebuilt) != len(parts) {
+			t.Fatalf("rebuild() = %d parts, %v, want %d parts", len(rebuilt), ok, len(parts))
+		}
+		if state.remaining() != 0 {
+			t.Errorf("remaining() = %d after rebuilding the same project, want 0", state.remaining())
+		}
+	})
+	t.Run("changed item", func(t *testing.T) {
+		changed := batchProject(6)
+		changed.Hosts[5].IPv4 = "192.0.2.99"
+		sortProject(changed)
+		state := newBatchState(project.ID, "input", parts)
+		for i := range state.Batches {
+			state.Batches[i].Status = batchAcked
+		}
+		if _, ok := state.rebuild(changed); !ok {
+			t.Fatal("rebuild() of a project with the same counts failed")
+		}
+		if state.remaining() != 1 {
+			t.Errorf("remaining() = %d, want the changed part only", state.remaining())
+		}
+	})
+	t.Run("different counts", func(t *testing.T) {
+		for _, other := range []*lair.Project{batchProject(5), batchProject(7)} {
+			if _, ok := state.rebuild(other); ok {
+				t.Errorf("rebuild() of %d hosts succeeded for a state of 6", len(other.Hosts))
+			}
+		}
+	})
+}
+
+func TestSendBatches(t *testing.T) {
+	project := batchProject(6)
+	sortProject(project)
+	parts, err := splitProject(project, jsonSize(project)/3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	f, c := newFakeLair(t, 2)
+	state := newBatchState(project.ID, "input", parts)
+	if err := sendBatches(c, state, parts, path); err == nil {
+		t.Fatal("sendBatches() succeeded with a failing part")
+	}
+	saved, err := readBatchState(path)
+	if err != nil || saved == nil {
+		t.Fatalf("readBatchState() = %v, %v, want the state of the failed import", saved, err)
+	}
+	if saved.Batches[0].Status != batchAcked || saved.Batches[1].Status != batchFailed || saved.Batches[2].Status != batchPending {
+		t.Errorf("batch statuses = %+v, want acked, failed, pending", saved.Batches)
+	}
+	if len(f.imports) != 2 {
+		t.Errorf("sent %d parts, want 2", len(f.imports))
+	}
+
+	f, c = newFakeLair(t)
+	if err := sendBatches(c, saved, parts, path); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.imports) != len(parts)-1 {
+		t.Errorf("resumed import sent %d parts, want %d", len(f.imports), len(parts)-1)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("state file left behind after a complete import: %v", err)
+	}
+}
+
+func TestImportProjectReplacesStaleState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	stale := batchProject(6)
+	sortProject(stale)
+	parts, err := splitProject(stale, jsonSize(stale)/3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := newBatchState("p1", "input", parts).write(path); err != nil {
+		t.Fatal(err)
+	}
+
+	f, c := newFakeLair(t)
+	if err := importProject(c, batchProject(1), 0, path, "input"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.imports) != 1 {
+		t.Errorf("sent %d parts, want 1", len(f.imports))
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stale state file left behind after a single part import: %v", err)
+	}
+}
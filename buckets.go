@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// bucketProvider returns the cloud provider of a STORAGE_BUCKET event, from
+// its cloud-<provider> tag or the bucket_<provider> module that found it.
+func bucketProvider(entry map[string]interface{}) string {
+	tags, _ := entry["tags"].([]interface{})
+	for _, tag := range tags {
+		if s, _ := tag.(string); strings.HasPrefix(s, "cloud-") {
+			return strings.TrimPrefix(s, "cloud-")
+		}
+	}
+	module, _ := entry["module"].(string)
+	if strings.HasPrefix(module, "bucket_") {
+		return strings.TrimPrefix(module, "bucket_")
+	}
+	return "unknown"
+}
+
+// isOpenBucket reports whether bbot found the bucket of a STORAGE_BUCKET event
+// to be publicly readable.
+func isOpenBucket(entry map[string]interface{}) bool {
+	tags, _ := entry["tags"].([]interface{})
+	for _, tag := range tags {
+		if s, _ := tag.(string); s == "open-bucket" || s == "public-bucket" {
+			return true
+		}
+	}
+	data, _ := entry["data"].(map[string]interface{})
+	open, _ := data["open"].(bool)
+	return open
+}
+
+// handleStorageBucket records a STORAGE_BUCKET event as an issue listing the
+// bucket's URL and provider, with a separate issue for publicly readable
+// buckets.
+func (im *importer) handleStorageBucket(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	name, _ := data["name"].(string)
+	rawURL, _ := data["url"].(string)
+	if rawURL == "" && name == "" {
+		return
+	}
+	open := isOpenBucket(entry)
+	evidence := fmt.Sprintf("%s (%s", rawURL, bucketProvider(entry))
+	if name != "" {
+		evidence += ", bucket " + name
+	}
+	if open {
+		evidence += ", publicly readable"
+	}
+	evidence = strings.TrimSpace(evidence + ")")
+
+	issue := newIssue("storage-bucket", "Cloud Storage Bucket Discovered", 0,
+		"bbot discovered cloud storage buckets that appear to belong to the organization. Buckets are often forgotten, and may be readable or writable by anyone.",
+		"Confirm that each bucket belongs to the organization, and that its permissions only allow the access it requires.")
+	if open {
+		issue = newIssue("storage-bucket-open", "Publicly Readable Cloud Storage Bucket", 5.0,
+			"bbot found cloud storage buckets whose contents can be listed or read without authentication. Public buckets frequently expose backups, credentials and personal data.",
+			"Remove public access from the bucket unless it is intended to serve public content, and review its contents for sensitive data.")
+	}
+	if module, _ := entry["module"].(string); module != "" {
+		issue.IdentifiedBy = append(issue.IdentifiedBy, lair.IdentifiedBy{Tool: "bbot " + module})
+	}
+	port := 0
+	if rawURL != "" {
+		port = urlPort(rawURL)
+	}
+	issue.Hosts = issueHosts(entry, port)
+	issue.Evidence = evidence
+	im.addIssue(issue)
+}
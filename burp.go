@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type burpScopeRule struct {
+	Enabled  bool   `json:"enabled"`
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	File     string `json:"file"`
+}
+
+type burpConfig struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool            `json:"advanced_mode"`
+			Include      []burpScopeRule `json:"include"`
+			Exclude      []burpScopeRule `json:"exclude"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+// recordURL remembers a web endpoint seen during the import for -export-burp.
+func (im *importer) recordURL(rawURL string) {
+	if im.opts.exportBurp == "" || rawURL == "" {
+		return
+	}
+	im.urls[rawURL] = true
+}
+
+// urlListPath returns the path of the plain URL list written next to the Burp
+// configuration at path, e.g. targets.json produces targets.txt.
+func urlListPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".txt"
+}
+
+// writeBurpExport writes a Burp Suite project configuration scoping every
+// scheme, host and port in urls to path, and the sorted URLs to urlListPath.
+func writeBurpExport(path string, urls map[string]bool) error {
+	list := []string{}
+	for u := range urls {
+		list = append(list, u)
+	}
+	sort.Strings(list)
+
+	config := burpConfig{}
+	config.Target.Scope.AdvancedMode = true
+	config.Target.Scope.Include = []burpScopeRule{}
+	config.Target.Scope.Exclude = []burpScopeRule{}
+	seen := make(map[string]bool)
+	for _, raw := range list {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		key := u.Scheme + "://" + u.Hostname() + ":" + port
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		config.Target.Scope.Include = append(config.Target.Scope.Include, burpScopeRule{
+			Enabled:  true,
+			Protocol: u.Scheme,
+			Host:     "^" + regexp.QuoteMeta(u.Hostname()) + "$",
+			Port:     "^" + port + "$",
+			File:     "^/.*",
+		})
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(urlListPath(path), []byte(strings.Join(list, "\n")+"\n"), 0644)
+}
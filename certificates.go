@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// certificateNotePrefix starts the title of certificate notes, which name the
+// certificate fingerprint so that a renewed certificate gets a note of its own.
+const certificateNotePrefix = "drone-bbot: TLS certificate "
+
+// certificate is the TLS certificate of a service, as reported in the tls
+// object of httpx output carried by HTTP_RESPONSE events.
+type certificate struct {
+	ip          string
+	port        int
+	subject     string
+	issuer      string
+	names       []string
+	notBefore   string
+	notAfter    time.Time
+	selfSigned  bool
+	fingerprint string
+}
+
+// parseCertificate extracts the certificate from an httpx tls object. It
+// returns false when the object has no subject or expiry.
+func parseCertificate(tls map[string]interface{}) (certificate, bool) {
+	var cert certificate
+	cert.subject, _ = tls["subject_dn"].(string)
+	if cert.subject == "" {
+		cert.subject, _ = tls["subject_cn"].(string)
+	}
+	cert.issuer, _ = tls["issuer_dn"].(string)
+	if cert.issuer == "" {
+		cert.issuer, _ = tls["issuer_cn"].(string)
+	}
+	names, _ := tls["subject_an"].([]interface{})
+	for _, name := range names {
+		if s, ok := name.(string); ok {
+			cert.names = append(cert.names, s)
+		}
+	}
+	cert.notBefore, _ = tls["not_before"].(string)
+	notAfter, _ := tls["not_after"].(string)
+	var err error
+	if cert.notAfter, err = time.Parse(time.RFC3339, notAfter); err != nil || cert.subject == "" {
+		return cert, false
+	}
+	cert.selfSigned, _ = tls["self_signed"].(bool)
+	if hashes, ok := tls["fingerprint_hash"].(map[string]interface{}); ok {
+		cert.fingerprint, _ = hashes["sha256"].(string)
+	}
+	if cert.fingerprint == "" {
+		cert.fingerprint = shortHash(cert.subject + "\n" + cert.issuer + "\n" + notAfter)
+	}
+	return cert, true
+}
+
+// content returns the text of the certificate note.
+func (c *certificate) content() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Subject: %s\n", c.subject)
+	fmt.Fprintf(&b, "Issuer: %s\n", c.issuer)
+	if len(c.names) > 0 {
+		fmt.Fprintf(&b, "Subject alternative names: %s\n", strings.Join(c.names, ", "))
+	}
+	if c.notBefore != "" {
+		fmt.Fprintf(&b, "Not before: %s\n", c.notBefore)
+	}
+	fmt.Fprintf(&b, "Not after: %s\n", c.notAfter.UTC().Format(time.RFC3339))
+	if c.selfSigned {
+		b.WriteString("Self-signed\n")
+	}
+	return sanitizeText(b.String())
+}
+
+// recordCertificate remembers the certificate in an HTTP_RESPONSE event for
+// its service, and with -cert-issues reports it as an issue when it is
+// self-signed or expires within -cert-expiry-days.
+func (im *importer) recordCertificate(entry map[string]interface{}, data map[string]interface{}, rawURL string) {
+	tls, _ := data["tls"].(map[string]interface{})
+	if tls == nil {
+		return
+	}
+	cert, ok := parseCertificate(tls)
+	if !ok {
+		return
+	}
+	cert.port = urlPort(rawURL)
+	if port, err := strconv.Atoi(fmt.Sprint(tls["port"])); err == nil && port > 0 {
+		cert.port = port
+	}
+	for _, ip := range im.webIPs(entry) {
+		c := cert
+		c.ip = ip
+		im.certificates[fmt.Sprintf("%s:%d", ip, cert.port)] = &c
+	}
+	if im.opts.certIssues {
+		im.checkCertificate(entry, cert)
+	}
+}
+
+// checkCertificate records issues for a self-signed certificate and one that
+// has expired or expires within -cert-expiry-days.
+func (im *importer) checkCertificate(entry map[string]interface{}, cert certificate) {
+	evidence := fmt.Sprintf("Subject: %s, issuer: %s, expires %s", cert.subject, cert.issuer, cert.notAfter.UTC().Format("2006-01-02"))
+	if cert.selfSigned {
+		issue := newIssue("tls-cert-self-signed", "Self-Signed TLS Certificate", 0,
+			"The service presents a self-signed certificate, which clients can not verify. Users learn to accept certificate warnings, leaving them exposed to man-in-the-middle attacks.",
+			"Replace the certificate with one issued by a trusted certificate authority.")
+		issue.Hosts = issueHosts(entry, cert.port)
+		issue.Evidence = evidence
+		im.addIssue(issue)
+	}
+	if time.Until(cert.notAfter) < time.Duration(im.opts.certExpiryDays)*24*time.Hour {
+		issue := newIssue("tls-cert-expiring", "TLS Certificate Expired or Expiring Soon", 0,
+			fmt.Sprintf("The service presents a certificate that has expired or expires within %d days. Expired certificates cause client errors and outages.", im.opts.certExpiryDays),
+			"Renew the certificate before it expires, and monitor certificate expiry.")
+		issue.Hosts = issueHosts(entry, cert.port)
+		issue.Evidence = evidence
+		im.addIssue(issue)
+	}
+}
+
+// applyCertificates adds a note describing each recorded certificate to its
+// service. Hosts that are not in the project are skipped.
+func (im *importer) applyCertificates() {
+	keys := []string{}
+	for key := range im.certificates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cert := im.certificates[key]
+		im.updateHost(cert.ip, func(host *lair.Host) bool {
+			service := ensureService(host, cert.port, "tcp", "https")
+			title := certificateNotePrefix + cert.fingerprint
+			if hasNote(service.Notes, title) {
+				return false
+			}
+			service.Notes = append(service.Notes, lair.Note{
+				Title:          title,
+				Content:        im.limitEvidence(cert.content()),
+				LastModifiedBy: lastModifiedBy,
+			})
+			return true
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// lairRequest sends a request for project lairPID with the client's
+// credentials and returns the response status and up to 1MB of the body.
+func lairRequest(c *client.C, method, lairPID string, body []byte) (int, []byte, error) {
+	reqURL := &url.URL{Scheme: c.Scheme, Host: c.Host, Path: "/api/projects/" + lairPID}
+	req, err := http.NewRequest(method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.User, c.Password)
+	res, err := (&http.Client{Transport: c.Transport}).Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	return res.StatusCode, data, err
+}
+
+// checkAuth verifies that the client's credentials can export and import
+// project lairPID without changing it. Import permission is tested with an
+// import that Lair rejects as invalid only after authorizing it, as every
+// valid import appends a command to the project.
+func checkAuth(c *client.C, lairPID string) error {
+	status, data, err := lairRequest(c, http.MethodGet, lairPID, nil)
+	if err != nil {
+		return fmt.Errorf("unable to reach Lair: %s", err.Error())
+	}
+	if err := authStatusError("export", status, data); err != nil {
+		return err
+	}
+	var project lair.Project
+	if err := json.Unmarshal(data, &project); err != nil || project.ID != lairPID {
+		return fmt.Errorf("export: project %s was not returned", lairPID)
+	}
+
+	probe, _ := json.Marshal(lair.Project{ID: lairPID, Tool: tool})
+	status, data, err = lairRequest(c, http.MethodPatch, lairPID, probe)
+	if err != nil {
+		return fmt.Errorf("unable to reach Lair: %s", err.Error())
+	}
+	if status == http.StatusBadRequest {
+		return nil
+	}
+	if err := authStatusError("import", status, data); err != nil {
+		return err
+	}
+	return fmt.Errorf("import: unexpected response %d to an import without a command", status)
+}
+
+// authStatusError describes a failed export or import check.
+func authStatusError(step string, status int, body []byte) error {
+	switch status {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%s: credentials were rejected", step)
+	case http.StatusForbidden:
+		return fmt.Errorf("%s: not permitted on this project", step)
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: project not found", step)
+	}
+	return fmt.Errorf("%s: Lair responded %d: %s", step, status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCheckpoint returns the number of lines a previous partial import of the
+// same file consumed, or 0 when there is no checkpoint at path.
+func readCheckpoint(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	lines, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || lines < 0 {
+		return 0, fmt.Errorf("%s does not contain a line count", path)
+	}
+	return lines, nil
+}
+
+// writeCheckpoint records that the first lines lines of the file have been
+// imported. A count of 0 means the import completed and removes the checkpoint.
+func writeCheckpoint(path string, lines int) error {
+	if lines == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(lines)+"\n"), 0644)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// cloudProviders maps the provider names in bbot's cloud-<provider> event tags
+// to the provider used in cloud:<provider> host tags.
+var cloudProviders = map[string]string{
+	"amazon":    "aws",
+	"aws":       "aws",
+	"google":    "gcp",
+	"gcp":       "gcp",
+	"microsoft": "azure",
+	"azure":     "azure",
+}
+
+// cloudProvider returns the provider of a bbot cloud-<provider> event tag, or
+// an empty string for other tags and for cloud-ip and cloud-domain.
+func cloudProvider(tag string) string {
+	if !strings.HasPrefix(tag, "cloud-") {
+		return ""
+	}
+	provider := strings.ToLower(strings.TrimPrefix(tag, "cloud-"))
+	if mapped, ok := cloudProviders[provider]; ok {
+		return mapped
+	}
+	if provider == "ip" || provider == "domain" || cpeUnsafePattern.MatchString(provider) {
+		return ""
+	}
+	return provider
+}
+
+// recordCloud records the cloud providers in the tags of an event for the IPs
+// of the event, and the domains of an AZURE_TENANT event.
+func (im *importer) recordCloud(entry map[string]interface{}) {
+	if entry["type"] == "AZURE_TENANT" {
+		data, _ := entry["data"].(map[string]interface{})
+		domains, _ := data["domains"].([]interface{})
+		for _, d := range domains {
+			if domain, _ := d.(string); domain != "" {
+				im.azureTenants[strings.ToLower(sanitizeText(domain))] = true
+			}
+		}
+		return
+	}
+	tags, _ := entry["tags"].([]interface{})
+	for _, t := range tags {
+		tag, _ := t.(string)
+		provider := cloudProvider(tag)
+		if provider == "" {
+			continue
+		}
+		for _, ip := range eventIPs(entry) {
+			if im.cloud[ip] == nil {
+				im.cloud[ip] = make(map[string]bool)
+			}
+			im.cloud[ip][provider] = true
+		}
+	}
+}
+
+// skipCloudHosts drops the new hosts whose IP bbot attributed to a cloud
+// provider with -skip-cloud-ips. Cloud IPs are often reassigned, so a host
+// created for one may belong to someone else by the time it is tested.
+// Existing hosts are still updated.
+func (im *importer) skipCloudHosts() {
+	if !im.opts.skipCloudIPs {
+		return
+	}
+	kept := im.project.Hosts[:0]
+	skipped := []string{}
+	for _, host := range im.project.Hosts {
+		if len(im.cloud[host.IPv4]) > 0 {
+			skipped = append(skipped, host.IPv4)
+			continue
+		}
+		kept = append(kept, host)
+	}
+	im.project.Hosts = kept
+	if len(skipped) > 0 {
+		sortIPs(skipped)
+		logf("Skipped %d new hosts with cloud provider IPs: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+}
+
+// applyCloudTags tags each host with cloud:<provider> for the cloud providers
+// recorded for its IP, and with cloud:azure-tenant when one of its hostnames
+// is in the domain of an Azure tenant.
+func (im *importer) applyCloudTags() {
+	ips := []string{}
+	for ip := range im.cloud {
+		ips = append(ips, ip)
+	}
+	sortIPs(ips)
+	for _, ip := range ips {
+		providers := []string{}
+		for provider := range im.cloud[ip] {
+			providers = append(providers, provider)
+		}
+		sort.Strings(providers)
+		im.updateHost(ip, func(host *lair.Host) bool {
+			changed := false
+			for _, provider := range providers {
+				var added bool
+				host.Tags, added = appendUnique(host.Tags, im.opts.namespaceTag("cloud:"+provider))
+				changed = changed || added
+			}
+			return changed
+		})
+	}
+	if len(im.azureTenants) == 0 {
+		return
+	}
+	tenant := func(host *lair.Host) bool {
+		for _, name := range host.Hostnames {
+			if im.azureTenants[strings.ToLower(name)] || im.azureTenants[registrableDomain(name)] {
+				var added bool
+				host.Tags, added = appendUnique(host.Tags, im.opts.namespaceTag("cloud:azure-tenant"))
+				return added
+			}
+		}
+		return false
+	}
+	for ip := range im.existingIPs {
+		im.updateHost(ip, tenant)
+	}
+	for i := range im.project.Hosts {
+		tenant(&im.project.Hosts[i])
+	}
+}
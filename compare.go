@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scanState is what compare extracts from a bbot scan: the IPs each DNS name
+// resolved to and the open ports of each IP.
+type scanState struct {
+	names map[string]map[string]bool
+	ports map[string]map[int]bool
+}
+
+// nameChange is a DNS name that resolved to different IPs in two scans.
+type nameChange struct {
+	name string
+	old  []string
+	new  []string
+}
+
+// comparison is the difference between two bbot scans.
+type comparison struct {
+	added    []string
+	removed  []string
+	changed  []nameChange
+	newPorts map[string][]int
+}
+
+// eachEvent calls fn with every line of the bbot output at path and the event
+// decoded from it.
+func eachEvent(path string, fn func(line []byte, entry map[string]interface{})) error {
+	in, err := openInput(path, "", eventFilter{})
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	scanner := newEventScanner(in)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("%s line %d: could not parse bbot JSON: %s", path, n, err.Error())
+		}
+		fn(line, entry)
+	}
+	return scanner.Err()
+}
+
+// openPortEvent returns the IPs and port of an OPEN_TCP_PORT event.
+func openPortEvent(entry map[string]interface{}) ([]string, int, bool) {
+	data, _ := entry["data"].(string)
+	host, rawPort, err := net.SplitHostPort(data)
+	if err != nil {
+		return nil, 0, false
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, 0, false
+	}
+	ips := eventIPs(entry)
+	if net.ParseIP(host) != nil {
+		ips, _ = appendUnique(ips, host)
+	}
+	return ips, port, true
+}
+
+// readScanState reads the DNS names and open ports of the scan at path.
+func readScanState(path string) (*scanState, error) {
+	state := &scanState{names: make(map[string]map[string]bool), ports: make(map[string]map[int]bool)}
+	err := eachEvent(path, func(line []byte, entry map[string]interface{}) {
+		switch entry["type"] {
+		case "DNS_NAME":
+			name, _ := entry["host"].(string)
+			if name == "" {
+				return
+			}
+			name = strings.ToLower(name)
+			if state.names[name] == nil {
+				state.names[name] = make(map[string]bool)
+			}
+			for _, ip := range resolvedHosts(entry) {
+				state.names[name][ip] = true
+			}
+		case "OPEN_TCP_PORT":
+			ips, port, ok := openPortEvent(entry)
+			if !ok {
+				return
+			}
+			for _, ip := range ips {
+				if state.ports[ip] == nil {
+					state.ports[ip] = make(map[int]bool)
+				}
+				state.ports[ip][port] = true
+			}
+		}
+	})
+	return state, err
+}
+
+// sortedKeys returns the keys of set, sorted as IPs.
+func sortedKeys(set map[string]bool) []string {
+	keys := []string{}
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sortIPs(keys)
+	return keys
+}
+
+// compareScans returns the DNS names added to and removed from the new scan,
+// the names that resolve to different IPs, and the ports open in the new scan
+// but not in the old one.
+func compareScans(oldState, newState *scanState) *comparison {
+	cmp := &comparison{added: []string{}, removed: []string{}, changed: []nameChange{}, newPorts: make(map[string][]int)}
+	for name, ips := range newState.names {
+		oldIPs, found := oldState.names[name]
+		if !found {
+			cmp.added = append(cmp.added, name)
+			continue
+		}
+		before, after := sortedKeys(oldIPs), sortedKeys(ips)
+		if strings.Join(before, ",") != strings.Join(after, ",") {
+			cmp.changed = append(cmp.changed, nameChange{name: name, old: before, new: after})
+		}
+	}
+	for name := range oldState.names {
+		if _, found := newState.names[name]; !found {
+			cmp.removed = append(cmp.removed, name)
+		}
+	}
+	for ip, ports := range newState.ports {
+		for port := range ports {
+			if !oldState.ports[ip][port] {
+				cmp.newPorts[ip] = append(cmp.newPorts[ip], port)
+			}
+		}
+		sort.Ints(cmp.newPorts[ip])
+	}
+	sort.Strings(cmp.added)
+	sort.Strings(cmp.removed)
+	sort.Slice(cmp.changed, func(i, j int) bool { return cmp.changed[i].name < cmp.changed[j].name })
+	return cmp
+}
+
+// lines formats the comparison as a report, one line per difference.
+func (cmp *comparison) lines() []string {
+	lines := []string{fmt.Sprintf("%d hostnames added, %d removed, %d resolve to different IPs, %d IPs with new ports",
+		len(cmp.added), len(cmp.removed), len(cmp.changed), len(cmp.newPorts))}
+	for _, name := range cmp.added {
+		lines = append(lines, "+ "+name)
+	}
+	for _, name := range cmp.removed {
+		lines = append(lines, "- "+name)
+	}
+	for _, change := range cmp.changed {
+		lines = append(lines, fmt.Sprintf("~ %s %s -> %s", change.name, strings.Join(change.old, ","), strings.Join(change.new, ",")))
+	}
+	ips := []string{}
+	for ip := range cmp.newPorts {
+		ips = append(ips, ip)
+	}
+	sortIPs(ips)
+	for _, ip := range ips {
+		ports := []string{}
+		for _, port := range cmp.newPorts[ip] {
+			ports = append(ports, strconv.Itoa(port))
+		}
+		lines = append(lines, fmt.Sprintf("> %s ports %s", ip, strings.Join(ports, ",")))
+	}
+	return lines
+}
+
+// deltaEvents returns the events of the scan at path that make up cmp, the
+// DNS_NAME events of added names and of names that resolve to different IPs,
+// and the OPEN_TCP_PORT events of new ports, as NDJSON.
+func deltaEvents(path string, cmp *comparison) ([]byte, error) {
+	names := make(map[string]bool)
+	for _, name := range cmp.added {
+		names[name] = true
+	}
+	for _, change := range cmp.changed {
+		names[change.name] = true
+	}
+	var delta bytes.Buffer
+	err := eachEvent(path, func(line []byte, entry map[string]interface{}) {
+		include := false
+		switch entry["type"] {
+		case "DNS_NAME":
+			name, _ := entry["host"].(string)
+			include = names[strings.ToLower(name)]
+		case "OPEN_TCP_PORT":
+			ips, port, ok := openPortEvent(entry)
+			for _, ip := range ips {
+				for _, p := range cmp.newPorts[ip] {
+					include = include || (ok && p == port)
+				}
+			}
+		}
+		if include {
+			delta.Write(line)
+			delta.WriteByte('\n')
+		}
+	})
+	return delta.Bytes(), err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newScanState builds a scanState from DNS names mapped to IPs and IPs mapped
+// to open ports.
+func newScanState(names map[string][]string, ports map[string][]int) *scanState {
+	state := &scanState{names: make(map[string]map[string]bool), ports: make(map[string]map[int]bool)}
+	for name, ips := range names {
+		state.names[name] = make(map[string]bool)
+		for _, ip := range ips {
+			state.names[name][ip] = true
+		}
+	}
+	for ip, list := range ports {
+		state.ports[ip] = make(map[int]bool)
+		for _, port := range list {
+			state.ports[ip][port] = true
+		}
+	}
+	return state
+}
+
+func TestCompareScans(t *testing.T) {
+	old := newScanState(
+		map[string][]string{
+			"www.example.com":  {"192.0.2.1"},
+			"mail.example.com": {"192.0.2.2"},
+			"api.example.com":  {"192.0.2.10", "192.0.2.3"},
+		},
+		map[string][]int{"192.0.2.1": {80, 443}},
+	)
+	tests := []struct {
+		name     string
+		new      *scanState
+		want     *comparison
+		wantLine string
+	}{
+		{
+			name:     "same scan",
+			new:      old,
+			want:     &comparison{added: []string{}, removed: []string{}, changed: []nameChange{}, newPorts: map[string][]int{}},
+			wantLine: "0 hostnames added, 0 removed, 0 resolve to different IPs, 0 IPs with new ports",
+		},
+		{
+			name: "same IPs in another order",
+			new: newScanState(map[string][]string{
+				"www.example.com":  {"192.0.2.1"},
+				"mail.example.com": {"192.0.2.2"},
+				"api.example.com":  {"192.0.2.3", "192.0.2.10"},
+			}, nil),
+			want:     &comparison{added: []string{}, removed: []string{}, changed: []nameChange{}, newPorts: map[string][]int{}},
+			wantLine: "0 hostnames added, 0 removed, 0 resolve to different IPs, 0 IPs with new ports",
+		},
+		{
+			name: "changes",
+			new: newScanState(
+				map[string][]string{
+					"www.example.com": {"192.0.2.1"},
+					"api.example.com": {"192.0.2.3", "192.0.2.4"},
+					"vpn.example.com": {"192.0.2.5"},
+					"dev.example.com": {"192.0.2.6"},
+				},
+				map[string][]int{"192.0.2.1": {443, 8443, 22}, "192.0.2.5": {443}},
+			),
+			want: &comparison{
+				added:    []string{"dev.example.com", "vpn.example.com"},
+				removed:  []string{"mail.example.com"},
+				changed:  []nameChange{{name: "api.example.com", old: []string{"192.0.2.3", "192.0.2.10"}, new: []string{"192.0.2.3", "192.0.2.4"}}},
+				newPorts: map[string][]int{"192.0.2.1": {22, 8443}, "192.0.2.5": {443}},
+			},
+			wantLine: "2 hostnames added, 1 removed, 1 resolve to different IPs, 2 IPs with new ports",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareScans(old, tt.new)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareScans() = %+v, want %+v", got, tt.want)
+			}
+			if line := got.lines()[0]; line != tt.wantLine {
+				t.Errorf("lines()[0] = %q, want %q", line, tt.wantLine)
+			}
+		})
+	}
+}
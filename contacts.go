@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// contactGroup is the group of the people created for WHOIS and RDAP contacts.
+const contactGroup = "registrar-contact"
+
+// contactRoles are the WHOIS contact roles imported as people.
+var contactRoles = []string{"registrant", "admin", "tech", "abuse", "billing"}
+
+// contact is a WHOIS or RDAP contact of a domain.
+type contact struct {
+	role    string
+	name    string
+	org     string
+	email   string
+	phone   string
+	address string
+}
+
+// contactValue returns a contact field, or an empty string for values that
+// registrars redact for privacy.
+func contactValue(v interface{}) string {
+	s := strings.TrimSpace(fmt.Sprint(v))
+	if v == nil || strings.Contains(strings.ToLower(s), "redacted") {
+		return ""
+	}
+	return sanitizeText(s)
+}
+
+// whoisContacts returns the contacts of a WHOIS event, whose data holds a map
+// per role with name, organization, email, phone and address.
+func whoisContacts(data map[string]interface{}) []contact {
+	contacts := []contact{}
+	for _, role := range contactRoles {
+		fields, _ := data[role].(map[string]interface{})
+		if fields == nil {
+			continue
+		}
+		contacts = append(contacts, contact{
+			role:    role,
+			name:    contactValue(fields["name"]),
+			org:     contactValue(fields["organization"]),
+			email:   strings.ToLower(contactValue(fields["email"])),
+			phone:   contactValue(fields["phone"]),
+			address: contactValue(fields["address"]),
+		})
+	}
+	return contacts
+}
+
+// rdapContacts returns the contacts in the entities of an RDAP response,
+// including nested entities such as a registrar's abuse contact.
+func rdapContacts(entities []interface{}) []contact {
+	contacts := []contact{}
+	for _, e := range entities {
+		entity, _ := e.(map[string]interface{})
+		if entity == nil {
+			continue
+		}
+		roles, _ := entity["roles"].([]interface{})
+		c := contact{}
+		for _, r := range roles {
+			if role, _ := r.(string); c.role == "" && role != "" {
+				c.role = role
+			}
+		}
+		vcard, _ := entity["vcardArray"].([]interface{})
+		if len(vcard) == 2 {
+			properties, _ := vcard[1].([]interface{})
+			for _, p := range properties {
+				property, _ := p.([]interface{})
+				if len(property) < 4 {
+					continue
+				}
+				name, _ := property[0].(string)
+				value := property[3]
+				switch name {
+				case "fn":
+					c.name = contactValue(value)
+				case "org":
+					c.org = contactValue(value)
+				case "email":
+					c.email = strings.ToLower(contactValue(value))
+				case "tel":
+					c.phone = strings.TrimPrefix(contactValue(value), "tel:")
+				case "adr":
+					if parts, ok := value.([]interface{}); ok {
+						lines := []string{}
+						for _, part := range parts {
+							if s := contactValue(part); s != "" && s != "[]" {
+								lines = append(lines, s)
+							}
+						}
+						c.address = strings.Join(lines, ", ")
+					}
+				}
+			}
+		}
+		if c.role != "" {
+			contacts = append(contacts, c)
+		}
+		nested, _ := entity["entities"].([]interface{})
+		contacts = append(contacts, rdapContacts(nested)...)
+	}
+	return contacts
+}
+
+// handleRegistration records the contacts of a WHOIS or RDAP event that have
+// an email address or phone number as people.
+func (im *importer) handleRegistration(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	if data == nil {
+		return
+	}
+	domain, _ := data["domain"].(string)
+	if domain == "" {
+		domain, _ = entry["host"].(string)
+	}
+	contacts := whoisContacts(data)
+	entities, _ := data["entities"].([]interface{})
+	contacts = append(contacts, rdapContacts(entities)...)
+	module, _ := entry["module"].(string)
+	eventType, _ := entry["type"].(string)
+	for _, c := range contacts {
+		if c.email == "" && c.phone == "" {
+			continue
+		}
+		person := lair.Person{
+			DisplayName: c.name,
+			Department:  c.org,
+			Address:     c.address,
+			Groups:      []string{contactGroup},
+		}
+		if person.DisplayName == "" {
+			person.DisplayName = c.org
+		}
+		person.Description = strings.TrimSpace(fmt.Sprintf("%s contact for %s from %s", c.role, sanitizeText(domain), eventType))
+		if module != "" {
+			person.Description += " (bbot " + module + ")"
+		}
+		if c.email != "" {
+			person.Emails = []string{c.email}
+		}
+		if c.phone != "" {
+			person.Phones = []string{c.phone}
+		}
+		key := personKey(person)
+		if _, found := im.people[key]; !found {
+			im.people[key] = person
+		}
+	}
+}
+
+// personKey identifies a person by name, emails and phones, so that a contact
+// listed for several domains or seen in earlier imports is created once.
+func personKey(p lair.Person) string {
+	return strings.ToLower(strings.Join([]string{p.DisplayName, strings.Join(p.Emails, ","), strings.Join(p.Phones, ",")}, "|"))
+}
+
+// applyPeople adds the recorded contacts that are not already people in the
+// project. Lair inserts every imported person without merging, so existing
+// people are left out.
+func (im *importer) applyPeople(existing []lair.Person) {
+	known := make(map[string]bool)
+	for _, p := range existing {
+		known[personKey(p)] = true
+	}
+	keys := []string{}
+	for key := range im.people {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		im.project.People = append(im.project.People, im.people[key])
+	}
+}
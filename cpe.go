@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// cpeNotePrefix starts the title of every CPE note. Each CPE gets its own note
+// so that Lair, which keeps only the first note with a given title, retains
+// every CPE seen across imports.
+const cpeNotePrefix = "drone-bbot: CPE "
+
+// cpeOSFragments are lower case fragments of technology names that identify an
+// operating system rather than an application.
+var cpeOSFragments = []string{"windows", "linux", "ubuntu", "debian", "centos", "red hat", "freebsd"}
+
+var (
+	cpeVersionPattern = regexp.MustCompile(`^v?[0-9][0-9a-z.\-_]*$`)
+	cpeUnsafePattern  = regexp.MustCompile(`[^a-z0-9._\-]+`)
+)
+
+// technologyCPE converts a technology reported by bbot, such as "nginx 1.18.0",
+// into a CPE 2.3 formatted string. Technologies that are already CPEs are
+// returned unchanged. The vendor is not known and is left as a wildcard.
+func technologyCPE(technology string) string {
+	technology = strings.TrimSpace(strings.ToLower(technology))
+	if strings.HasPrefix(technology, "cpe:") {
+		return technology
+	}
+	fields := strings.Fields(technology)
+	if len(fields) == 0 {
+		return ""
+	}
+	version := "*"
+	if len(fields) > 1 && cpeVersionPattern.MatchString(fields[len(fields)-1]) {
+		version = strings.TrimPrefix(fields[len(fields)-1], "v")
+		fields = fields[:len(fields)-1]
+	}
+	product := strings.Trim(cpeUnsafePattern.ReplaceAllString(strings.Join(fields, "_"), "_"), "_")
+	if product == "" {
+		return ""
+	}
+	part := "a"
+	for _, fragment := range cpeOSFragments {
+		if strings.Contains(technology, fragment) {
+			part = "o"
+			break
+		}
+	}
+	return fmt.Sprintf("cpe:2.3:%s:*:%s:%s:*:*:*:*:*:*:*", part, product, version)
+}
+
+// isOSCPE reports whether cpe names an operating system.
+func isOSCPE(cpe string) bool {
+	return strings.HasPrefix(cpe, "cpe:2.3:o:") || strings.HasPrefix(cpe, "cpe:/o:")
+}
+
+// recordCPE remembers the CPE of a TECHNOLOGY event for the web service it was
+// identified on, or for the host when it names an operating system.
+func (im *importer) recordCPE(entry map[string]interface{}, technology, rawURL string) {
+	cpe := technologyCPE(technology)
+	if cpe == "" {
+		return
+	}
+	port, scheme := 0, ""
+	if !isOSCPE(cpe) {
+		u, err := url.Parse(rawURL)
+		if err != nil || rawURL == "" {
+			return
+		}
+		port, scheme = urlPort(rawURL), u.Scheme
+	}
+	for _, ip := range eventIPs(entry) {
+		key := fmt.Sprintf("%s:%d", ip, port)
+		if im.cpes[key] == nil {
+			im.cpes[key] = &cpeSet{ip: ip, port: port, scheme: scheme, cpes: make(map[string]string)}
+		}
+		im.cpes[key].cpes[cpe] = technology
+	}
+}
+
+// cpeSet are the CPEs identified on a service, or on the host itself when port
+// is 0.
+type cpeSet struct {
+	ip     string
+	port   int
+	scheme string
+	cpes   map[string]string
+}
+
+// applyCPEs adds a note for each recorded CPE to its service or host. Hosts
+// that are not in the project are skipped.
+func (im *importer) applyCPEs() {
+	for _, set := range im.cpes {
+		cpes := []string{}
+		for cpe := range set.cpes {
+			cpes = append(cpes, cpe)
+		}
+		sort.Strings(cpes)
+		im.updateHost(set.ip, func(host *lair.Host) bool {
+			notes := &host.Notes
+			if set.port != 0 {
+				notes = &ensureService(host, set.port, "tcp", set.scheme).Notes
+			}
+			changed := false
+			for _, cpe := range cpes {
+				title := cpeNotePrefix + cpe
+				if hasNote(*notes, title) {
+					continue
+				}
+				*notes = append(*notes, lair.Note{
+					Title:          title,
+					Content:        sanitizeText(fmt.Sprintf("%s\n\nIdentified by bbot as %q", cpe, set.cpes[cpe])),
+					LastModifiedBy: lastModifiedBy,
+				})
+				changed = true
+			}
+			return changed
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentEventLimit is the number of processed lines kept for error reports.
+const recentEventLimit = 20
+
+// errorReportPath is the -error-report file written when drone-bbot exits on
+// a fatal error, or an empty string when no report is wanted.
+var errorReportPath string
+
+// releaseOnFatal is called by fatalf before exiting, which skips deferred
+// calls, to remove the -lock-file.
+var releaseOnFatal = func() {}
+
+// eventMeta describes a processed line without its content, which may hold
+// sensitive scan data.
+type eventMeta struct {
+	Line   int    `json:"line"`
+	Bytes  int    `json:"bytes"`
+	Type   string `json:"type,omitempty"`
+	Module string `json:"module,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+var recentEvents struct {
+	sync.Mutex
+	events []eventMeta
+}
+
+// recordEvent keeps the metadata of the most recently processed lines. entry
+// is nil when the line could not be parsed.
+func recordEvent(line int, raw []byte, entry map[string]interface{}, err error) {
+	meta := eventMeta{Line: line, Bytes: len(raw)}
+	if err != nil {
+		meta.Error = err.Error()
+	}
+	meta.Type, _ = entry["type"].(string)
+	meta.Module, _ = entry["module"].(string)
+	meta.ID, _ = entry["id"].(string)
+	recentEvents.Lock()
+	defer recentEvents.Unlock()
+	recentEvents.events = append(recentEvents.events, meta)
+	if len(recentEvents.events) > recentEventLimit {
+		recentEvents.events = recentEvents.events[len(recentEvents.events)-recentEventLimit:]
+	}
+}
+
+// errorReport is the diagnostics bundle written by -error-report.
+type errorReport struct {
+	Time          string            `json:"time"`
+	Version       string            `json:"version"`
+	Cause         string            `json:"cause"`
+	Args          []string          `json:"args"`
+	Config        map[string]string `json:"config"`
+	LairServer    string            `json:"lairServer"`
+	LairReachable string            `json:"lairReachable"`
+	RecentEvents  []eventMeta       `json:"recentEvents"`
+	Stack         string            `json:"stack"`
+}
+
+// secretFlags are the flags whose values are credentials.
+var secretFlags = map[string]bool{"shodan-key": true, "passive-dns-key": true}
+
+// redactURL removes the password from raw when it is a URL with credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+	return u.String()
+}
+
+// lairReachability reports whether a TCP connection can be made to the Lair
+// API server.
+func lairReachability() string {
+	u, err := url.Parse(os.Getenv("LAIR_API_SERVER"))
+	if err != nil || u.Host == "" {
+		return "LAIR_API_SERVER is missing or invalid"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(u.Hostname(), port), 5*time.Second)
+	if err != nil {
+		return err.Error()
+	}
+	conn.Close()
+	return "reachable"
+}
+
+// writeErrorReport writes a diagnostics bundle for cause to errorReportPath.
+// Credentials in flag values and the Lair URL are redacted, and only the
+// metadata of processed lines is included.
+func writeErrorReport(cause string) error {
+	report := errorReport{
+		Time:          time.Now().UTC().Format(time.RFC3339),
+		Version:       version,
+		Cause:         cause,
+		Args:          []string{},
+		Config:        make(map[string]string),
+		LairServer:    redactURL(os.Getenv("LAIR_API_SERVER")),
+		LairReachable: lairReachability(),
+		Stack:         string(debug.Stack()),
+	}
+	redactNext := false
+	for _, arg := range os.Args[1:] {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		isSecret := strings.HasPrefix(arg, "-") && secretFlags[name]
+		switch {
+		case redactNext:
+			arg = "REDACTED"
+			redactNext = false
+		case isSecret && hasValue:
+			arg = arg[:len(arg)-len(value)] + "REDACTED"
+		case isSecret:
+			redactNext = true
+		}
+		report.Args = append(report.Args, redactURL(arg))
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		report.Config[f.Name] = redactURL(f.Value.String())
+		if secretFlags[f.Name] && f.Value.String() != "" {
+			report.Config[f.Name] = "REDACTED"
+		}
+	})
+	recentEvents.Lock()
+	report.RecentEvents = append([]eventMeta{}, recentEvents.events...)
+	recentEvents.Unlock()
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(errorReportPath, data, 0600)
+}
+
+// fatalf logs a fatal error in the language selected with -lang, writing the
+// -error-report in English first, and exits.
+func fatalf(format string, v ...interface{}) {
+	if errorReportPath != "" {
+		if err := writeErrorReport(fmt.Sprintf(format, v...)); err != nil {
+			logf("Error: Unable to write error report. Error %s", err.Error())
+		} else {
+			logf("Wrote error report to %s", errorReportPath)
+		}
+	}
+	releaseOnFatal()
+	log.Fatal(paint(colorRed, fmt.Sprintf(tr(format), v...)))
+}
+
+// reportPanic writes the -error-report for a panic before letting it continue.
+// It must be deferred.
+func reportPanic() {
+	if r := recover(); r != nil {
+		if errorReportPath != "" {
+			if err := writeErrorReport(fmt.Sprintf("panic: %v", r)); err == nil {
+				logf("Wrote error report to %s", errorReportPath)
+			}
+		}
+		panic(r)
+	}
+}
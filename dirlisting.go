@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+)
+
+const directoryListingPluginID = "directory-listing"
+
+// directoryListingTitles are page titles produced by common web servers when
+// they generate a directory index.
+var directoryListingTitles = []string{
+	"index of /",
+	"directory listing for /",
+	"directory listing -- /",
+}
+
+// isDirectoryListingTitle reports whether an HTTP page title indicates an
+// automatically generated directory index.
+func isDirectoryListingTitle(title string) bool {
+	title = strings.ToLower(strings.TrimSpace(title))
+	for _, prefix := range directoryListingTitles {
+		if strings.HasPrefix(title, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDirectoryListingFinding reports whether a FINDING description reports an
+// open directory listing.
+func isDirectoryListingFinding(description string) bool {
+	description = strings.ToLower(description)
+	return strings.Contains(description, "directory listing") || strings.Contains(description, "open directory") ||
+		strings.Contains(description, "directory indexing")
+}
+
+// addDirectoryListing records a low severity issue for the directory index at
+// rawURL.
+func (im *importer) addDirectoryListing(entry map[string]interface{}, rawURL string) {
+	issue := newIssue(
+		directoryListingPluginID,
+		"Directory Listing Enabled",
+		2.6,
+		"The web server returns an automatically generated index of the files in one or more directories. "+
+			"Directory listings can disclose backup files, source code and other content that is not linked from the application.",
+		"Disable directory indexing in the web server configuration, or place an index page in each affected directory.",
+	)
+	issue.Hosts = issueHosts(entry, urlPort(rawURL))
+	issue.Evidence = rawURL
+	im.addIssue(issue)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// hostDisplays are the values accepted by -host-display.
+var hostDisplays = []string{"", "ip", "hostname"}
+
+// applyHostDisplay makes Lair show the hosts this import creates by name with
+// -host-display hostname. Lair lists a host by its IP and first hostname, so
+// the primary hostname is moved to the first slot. Lair appends the hostnames
+// of existing hosts, so their order is left alone. A host may be created by
+// several entries that Lair merges, so the primary hostname is chosen from the
+// names of every entry with the same IP.
+func (im *importer) applyHostDisplay() {
+	if im.opts.hostDisplay != "hostname" {
+		return
+	}
+	names := make(map[string][]string)
+	for _, host := range im.project.Hosts {
+		names[host.IPv4] = append(names[host.IPv4], host.Hostnames...)
+	}
+	for i := range im.project.Hosts {
+		host := &im.project.Hosts[i]
+		primary := im.primaryHostname(names[host.IPv4])
+		if primary == "" || host.IPv4 == unresolvedHostIP {
+			continue
+		}
+		names := []string{primary}
+		for _, name := range host.Hostnames {
+			if name != primary {
+				names = append(names, name)
+			}
+		}
+		host.Hostnames = names
+	}
+}
+
+// primaryHostname returns the hostname that best names a host: names in
+// -scope or a bbot target domain before others, then the names with the
+// fewest labels, the shortest and the first alphabetically. Wildcard names
+// are never chosen.
+func (im *importer) primaryHostname(names []string) string {
+	candidates := []string{}
+	for _, name := range names {
+		if name != "" && !strings.HasPrefix(name, "*") {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	scope := make(map[string]bool)
+	for target := range im.targets {
+		scope[target] = true
+	}
+	for _, domain := range im.opts.scope {
+		scope[registrableDomain(domain)] = true
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if inA, inB := scope[registrableDomain(a)], scope[registrableDomain(b)]; inA != inB {
+			return inA
+		}
+		if la, lb := strings.Count(a, "."), strings.Count(b, "."); la != lb {
+			return la < lb
+		}
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return candidates[0]
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// maxDNSChildDepth bounds how deeply nested dns_children are followed.
+const maxDNSChildDepth = 8
+
+// dnsChildren returns the dns_children of an event, which maps record types
+// to the values bbot resolved for the event's host.
+func dnsChildren(entry map[string]interface{}) map[string]interface{} {
+	children, _ := entry["dns_children"].(map[string]interface{})
+	return children
+}
+
+// childValues returns the values of a dns_children record type, which may be
+// a single value or a list.
+func childValues(raw interface{}) []interface{} {
+	if list, ok := raw.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{raw}
+}
+
+// childIPs returns the A and AAAA records in an event's dns_children, which
+// older bbot outputs include instead of resolved_hosts.
+func childIPs(entry map[string]interface{}) []string {
+	ips := []string{}
+	children := dnsChildren(entry)
+	for _, rtype := range []string{"A", "AAAA"} {
+		for _, value := range childValues(children[rtype]) {
+			if ip, ok := value.(string); ok && net.ParseIP(ip) != nil {
+				ips, _ = appendUnique(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+// handleDNSChildren imports the names nested in an event's dns_children.
+// CNAME targets resolve to the same addresses as the event's host, and nested
+// events carry their own resolution, other names such as MX and NS records are
+// not attached to a host.
+func (im *importer) handleDNSChildren(entry map[string]interface{}, resolved []string, depth int) {
+	children := dnsChildren(entry)
+	if depth >= maxDNSChildDepth || len(children) == 0 {
+		return
+	}
+	rtypes := []string{}
+	for rtype := range children {
+		rtypes = append(rtypes, rtype)
+	}
+	sort.Strings(rtypes)
+	for _, rtype := range rtypes {
+		for _, value := range childValues(children[rtype]) {
+			switch child := value.(type) {
+			case string:
+				name := strings.TrimSuffix(child, ".")
+				if strings.ToUpper(rtype) != "CNAME" || name == "" || net.ParseIP(name) != nil || len(resolved) == 0 {
+					continue
+				}
+				ips := make([]interface{}, len(resolved))
+				for i, ip := range resolved {
+					ips[i] = ip
+				}
+				im.importDNSName(map[string]interface{}{"type": "DNS_NAME", "host": name, "resolved_hosts": ips}, depth+1)
+			case map[string]interface{}:
+				im.importDNSName(child, depth+1)
+			}
+		}
+	}
+}
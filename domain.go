@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// applyDomainQuota limits hosts to at most max distinct IPs per registrable
+// domain of their first hostname. Hosts over the quota are dropped and returned
+// keyed by registrable domain.
+func applyDomainQuota(hosts []lair.Host, max int) ([]lair.Host, map[string][]string) {
+	kept := []lair.Host{}
+	overflow := make(map[string][]string)
+	accepted := make(map[string]map[string]bool)
+	for _, host := range hosts {
+		if len(host.Hostnames) == 0 {
+			kept = append(kept, host)
+			continue
+		}
+		domain := registrableDomain(host.Hostnames[0])
+		if accepted[domain] == nil {
+			accepted[domain] = make(map[string]bool)
+		}
+		if !accepted[domain][host.IPv4] && len(accepted[domain]) >= max {
+			overflow[domain] = append(overflow[domain], host.IPv4)
+			continue
+		}
+		accepted[domain][host.IPv4] = true
+		kept = append(kept, host)
+	}
+	return kept, overflow
+}
+
+// quotaNote summarizes the hosts dropped by applyDomainQuota.
+func quotaNote(overflow map[string][]string, max int) lair.Note {
+	domains := []string{}
+	for domain := range overflow {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	var b strings.Builder
+	fmt.Fprintf(&b, "The following domains exceeded the limit of %d hosts per domain and were not fully imported:\n", max)
+	for _, domain := range domains {
+		fmt.Fprintf(&b, "\n%s (%d hosts skipped)\n", domain, len(overflow[domain]))
+		ips := append([]string{}, overflow[domain]...)
+		sortIPs(ips)
+		for _, ip := range ips {
+			fmt.Fprintf(&b, "  %s\n", ip)
+		}
+	}
+	return lair.Note{
+		Title:          "drone-bbot: per-domain host quota exceeded",
+		Content:        sanitizeText(b.String()),
+		LastModifiedBy: lastModifiedBy,
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// formatReport renders an import summary as plain text.
+func formatReport(s *summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "drone-bbot import into project %s\n\n", s.Project)
+	switch {
+	case s.Refused != "":
+		fmt.Fprintf(&b, "The import was refused: %s.\n\n", s.Refused)
+	case s.Imported:
+		b.WriteString("The import completed successfully.\n\n")
+	default:
+		b.WriteString("No new hosts were imported.\n\n")
+	}
+	fmt.Fprintf(&b, "Hosts created: %d\n", s.HostsCreated)
+	for _, ip := range s.Created {
+		fmt.Fprintf(&b, "  + %s\n", ip)
+	}
+	fmt.Fprintf(&b, "Hosts updated: %d\n", s.HostsUpdated)
+	for _, ip := range s.Updated {
+		fmt.Fprintf(&b, "  ~ %s\n", ip)
+	}
+	if s.Metrics != nil {
+		b.WriteString("\nEvents:\n")
+		for _, line := range s.Metrics.table() {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	if len(s.NotFound) > 0 {
+		fmt.Fprintf(&b, "\nHosts with DNS names that do not exist in lair: %d\n", len(s.NotFound))
+		for _, line := range notFoundTable(s.NotFound) {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	if s.QA != nil {
+		fmt.Fprintf(&b, "\nQA: %d of %d sampled hostnames did not resolve to their host (%.0f%%)\n",
+			s.QA.Mismatched+s.QA.Unresolved, s.QA.Sampled, s.QA.rate())
+		for _, problem := range s.QA.Problems {
+			fmt.Fprintf(&b, "  %s\n", problem)
+		}
+	}
+	if len(s.Locked) > 0 {
+		fmt.Fprintf(&b, "\nLocked hosts that were not changed: %d\n", len(s.Locked))
+		for _, line := range lockedTable(s.Locked) {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	if len(s.Removed) > 0 {
+		fmt.Fprintf(&b, "\nRemoved hosts found again by bbot: %d\n", len(s.Removed))
+		for _, line := range lockedTable(s.Removed) {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// sendReport emails the summary of an import through the SMTP server at addr.
+// SMTP_USERNAME and SMTP_PASSWORD are used to authenticate when set.
+func sendReport(addr, from string, to []string, s *summary) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	subject := fmt.Sprintf("drone-bbot: %s, %d hosts created, %d hosts updated", s.Project, s.HostsCreated, s.HostsUpdated)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		from, strings.Join(to, ", "), subject, time.Now().Format(time.RFC1123Z), formatReport(s))
+	return smtp.SendMail(addr, auth, from, to, []byte(msg))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// emailNotePrefix starts the title of the project notes listing the email
+// addresses of a domain.
+const emailNotePrefix = "drone-bbot: email addresses @"
+
+// handleEmailAddress records the address of an EMAIL_ADDRESS event with
+// -email-notes.
+func (im *importer) handleEmailAddress(entry map[string]interface{}) {
+	if !im.opts.emailNotes {
+		return
+	}
+	data, _ := entry["data"].(string)
+	addr, err := mail.ParseAddress(strings.TrimSpace(data))
+	if err != nil {
+		return
+	}
+	address := strings.ToLower(addr.Address)
+	domain := address[strings.LastIndex(address, "@")+1:]
+	if im.emails[domain] == nil {
+		im.emails[domain] = make(map[string]bool)
+	}
+	im.emails[domain][address] = true
+}
+
+// emailNotes returns a project note per domain listing the recorded email
+// addresses.
+func (im *importer) emailNotes(existing []lair.Note) []lair.Note {
+	domains := []string{}
+	for domain := range im.emails {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	notes := []lair.Note{}
+	for _, domain := range domains {
+		lines := []string{}
+		for address := range im.emails[domain] {
+			lines = append(lines, address)
+		}
+		if note, ok := listNote(emailNotePrefix+domain, lines, existing); ok {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// listNote returns a project note titled title listing lines, identified by
+// their first word, that are not already listed in the existing notes with
+// that title. Lair keeps only the first note with a given title, so lines
+// found after the first note was created go into a numbered note of their
+// own. It returns false when there is nothing new to list.
+func listNote(title string, lines []string, existing []lair.Note) (lair.Note, bool) {
+	known := map[string]bool{}
+	count := 0
+	for _, note := range existing {
+		if note.Title != title && !strings.HasPrefix(note.Title, title+" (") {
+			continue
+		}
+		count++
+		for _, line := range strings.Split(note.Content, "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				known[fields[0]] = true
+			}
+		}
+	}
+	added := []string{}
+	for _, line := range lines {
+		if fields := strings.Fields(line); len(fields) > 0 && !known[fields[0]] {
+			added = append(added, line)
+		}
+	}
+	if len(added) == 0 {
+		return lair.Note{}, false
+	}
+	sort.Strings(added)
+	if count > 0 {
+		title = fmt.Sprintf("%s (%d)", title, count+1)
+	}
+	return lair.Note{
+		Title:          title,
+		Content:        sanitizeText(strings.Join(added, "\n") + "\n"),
+		LastModifiedBy: lastModifiedBy,
+	}, true
+}
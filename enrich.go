@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// enrichSources are the values accepted by -enrich.
+var enrichSources = []string{"shodan", "censys"}
+
+// enrichNotePrefix starts the title of the note listing the services an
+// enrichment source reported for a host.
+const enrichNotePrefix = "drone-bbot: exposed services reported by "
+
+// enrichRate is the number of lookups started per second, within the rate
+// limits of the Shodan and Censys APIs.
+const enrichRate = 1
+
+// errEnrichAuth is returned by lookup when the API rejects the credentials.
+var errEnrichAuth = errors.New("credentials were rejected")
+
+// exposedService is a service an enrichment source saw exposed on a host.
+type exposedService struct {
+	port     int
+	protocol string
+	name     string
+	product  string
+}
+
+// enricher looks up hosts in Shodan or Censys. The Shodan key is read from
+// -shodan-key or SHODAN_API_KEY, and Censys credentials from CENSYS_API_ID
+// and CENSYS_API_SECRET.
+type enricher struct {
+	source  string
+	baseURL string
+	key     string
+	id      string
+	secret  string
+	client  *http.Client
+}
+
+// newEnricher validates the enrichment options.
+func newEnricher(source, shodanKey string) (*enricher, error) {
+	e := &enricher{source: source, client: &http.Client{Timeout: 30 * time.Second}}
+	switch source {
+	case "shodan":
+		e.baseURL = "https://api.shodan.io"
+		e.key = shodanKey
+		if e.key == "" {
+			e.key = os.Getenv("SHODAN_API_KEY")
+		}
+		if e.key == "" {
+			return nil, errors.New("-shodan-key or SHODAN_API_KEY is required")
+		}
+	case "censys":
+		e.baseURL = "https://search.censys.io"
+		e.id, e.secret = os.Getenv("CENSYS_API_ID"), os.Getenv("CENSYS_API_SECRET")
+		if e.id == "" || e.secret == "" {
+			return nil, errors.New("CENSYS_API_ID and CENSYS_API_SECRET are required")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported source %q, expected %s", source, strings.Join(enrichSources, " or "))
+	}
+	return e, nil
+}
+
+// lookup returns the services the source reports exposed on ip. An IP the
+// source knows nothing about has no services.
+func (e *enricher) lookup(ip string) ([]exposedService, error) {
+	var reqURL string
+	if e.source == "shodan" {
+		reqURL = e.baseURL + "/shodan/host/" + url.PathEscape(ip) + "?key=" + url.QueryEscape(e.key)
+	} else {
+		reqURL = e.baseURL + "/api/v2/hosts/" + url.PathEscape(ip)
+	}
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e.source == "censys" {
+		req.SetBasicAuth(e.id, e.secret)
+	}
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, errors.New(strings.ReplaceAll(err.Error(), e.key, "REDACTED"))
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, errEnrichAuth
+	default:
+		return nil, fmt.Errorf("%s responded %d", e.source, res.StatusCode)
+	}
+	if e.source == "shodan" {
+		return shodanServices(data)
+	}
+	return censysServices(data)
+}
+
+// shodanServices returns the services in a Shodan host response.
+func shodanServices(data []byte) ([]exposedService, error) {
+	var host struct {
+		Data []struct {
+			Port      int    `json:"port"`
+			Transport string `json:"transport"`
+			Product   string `json:"product"`
+			Shodan    struct {
+				Module string `json:"module"`
+			} `json:"_shodan"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &host); err != nil {
+		return nil, err
+	}
+	services := []exposedService{}
+	for _, d := range host.Data {
+		services = append(services, exposedService{port: d.Port, protocol: d.Transport, name: d.Shodan.Module, product: d.Product})
+	}
+	return services, nil
+}
+
+// censysServices returns the services in a Censys host response.
+func censysServices(data []byte) ([]exposedService, error) {
+	var host struct {
+		Result struct {
+			Services []struct {
+				Port              int    `json:"port"`
+				TransportProtocol string `json:"transport_protocol"`
+				ServiceName       string `json:"service_name"`
+				Software          []struct {
+					Product string `json:"product"`
+				} `json:"software"`
+			} `json:"services"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &host); err != nil {
+		return nil, err
+	}
+	services := []exposedService{}
+	for _, s := range host.Result.Services {
+		service := exposedService{port: s.Port, protocol: strings.ToLower(s.TransportProtocol), name: strings.ToLower(s.ServiceName)}
+		if len(s.Software) > 0 {
+			service.product = s.Software[0].Product
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// enrichUnmatched looks up the hosts that had DNS names but do not exist in
+// Lair with -enrich, and creates those the source reports exposed services
+// on, with the services and a note naming the source. Other hosts stay
+// unmatched.
+func (im *importer) enrichUnmatched() error {
+	unmatched := []string{}
+	for ip := range im.bNotFound {
+		unmatched = append(unmatched, ip)
+	}
+	sortIPs(unmatched)
+	logf("Looking up %d hosts that do not exist in lair in %s", len(unmatched), im.opts.enricher.source)
+	ticker := time.NewTicker(time.Second / enrichRate)
+	defer ticker.Stop()
+	created := 0
+	for _, ip := range unmatched {
+		<-ticker.C
+		services, err := im.opts.enricher.lookup(ip)
+		if err == errEnrichAuth {
+			return fmt.Errorf("%s: %s", im.opts.enricher.source, err.Error())
+		}
+		if err != nil {
+			logf("Error: Unable to look up %s. Error %s", ip, err.Error())
+			continue
+		}
+		if len(services) == 0 {
+			continue
+		}
+		host := lair.Host{
+			IPv4:           ip,
+			Hostnames:      im.bNotFound[ip],
+			Tags:           im.opts.hostTags,
+			LastModifiedBy: lastModifiedBy,
+		}
+		sort.Slice(services, func(i, j int) bool { return services[i].port < services[j].port })
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s reported these services exposed on %s, so the host was created although it was not in Lair:\n\n", im.opts.enricher.source, ip)
+		for _, s := range services {
+			if s.protocol != "tcp" && s.protocol != "udp" || s.port < 1 || s.port > 65535 {
+				continue
+			}
+			service := ensureService(&host, s.port, s.protocol, sanitizeText(s.name))
+			service.Product = sanitizeText(s.product)
+			fmt.Fprintln(&b, strings.TrimSpace(fmt.Sprintf("%d/%s %s %s", s.port, s.protocol, s.name, s.product)))
+		}
+		if len(host.Services) == 0 {
+			continue
+		}
+		host.Notes = append(host.Notes, lair.Note{
+			Title:          enrichNotePrefix + im.opts.enricher.source,
+			Content:        sanitizeText(b.String()),
+			LastModifiedBy: lastModifiedBy,
+		})
+		im.project.Hosts = append(im.project.Hosts, host)
+		delete(im.bNotFound, ip)
+		created++
+	}
+	logf("Created %d hosts with services reported by %s", created, im.opts.enricher.source)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// limitEvidence cuts s, the content of a note or the evidence of an issue, to
+// -max-evidence-bytes so that large responses and certificates do not bloat
+// Lair documents. With -evidence-dir the full text is written to a file named
+// by its digest, which the truncated text points to.
+func (im *importer) limitEvidence(s string) string {
+	max := im.opts.maxEvidenceBytes
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	notice := fmt.Sprintf("\n[truncated from %d bytes]", len(s))
+	if im.opts.evidenceDir != "" {
+		sum := sha256.Sum256([]byte(s))
+		path := filepath.Join(im.opts.evidenceDir, hex.EncodeToString(sum[:])+".txt")
+		if err := os.WriteFile(path, []byte(s), 0600); err != nil {
+			logf("Warning: Unable to write evidence to %s. Error %s", path, err.Error())
+		} else {
+			notice = fmt.Sprintf("\n[truncated from %d bytes, the full text is in %s]", len(s), path)
+		}
+	}
+	return s[:cut] + notice
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/lair-framework/api-server/client"
+)
+
+// isFIFO reports whether path is a named pipe.
+func isFIFO(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// importFIFO imports from the named pipe at path indefinitely. Everything a
+// writer sends before closing the pipe is imported as one scan, after which
+// the pipe is reopened to wait for the next writer, so bbot can be restarted
+// or new scans launched without restarting the drone. Failed imports are
+// logged and do not stop the loop.
+func importFIFO(c *client.C, opts *options, lairPID, path string) {
+	for {
+		logf("Waiting for a writer on %s", path)
+		in, err := openInput(path, opts.format, opts.eventFilter())
+		if err != nil {
+			logf("Error: Unable to read from %s. Error %s", path, err.Error())
+			time.Sleep(time.Second)
+			continue
+		}
+		logf("Reading %s from %s", in.format, path)
+		s, err := run(c, opts, lairPID, in)
+		in.Close()
+		if err != nil {
+			logf("Error: Import into project %s failed. Error %s", lairPID, err.Error())
+			continue
+		}
+		if s.Refused != "" {
+			logf("Refused import into project %s: %s", lairPID, s.Refused)
+			continue
+		}
+		logf("Imported into project %s, %d hosts created, %d hosts updated", lairPID, s.HostsCreated, s.HostsUpdated)
+		s.Metrics.logStats()
+		if s.KnownAbsent > 0 {
+			logf("%d hosts that do not exist in lair were already reported", s.KnownAbsent)
+		}
+		if len(opts.emailRecipients) > 0 {
+			if err := sendReport(opts.smtpServer, opts.emailFrom, opts.emailRecipients, s); err != nil {
+				logf("Error: Unable to email the import summary. Error %s", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// findingModes are the values accepted by -findings.
+var findingModes = []string{"", "issue", "note"}
+
+// findingCVSS is the score of FINDING issues. Lair rates it low, as does
+// -min-severity, which treats a score of 0 as info, so that -min-severity low
+// keeps FINDING issues.
+const findingCVSS = 1.0
+
+// findingNote is a FINDING recorded as a note on the hosts it refers to.
+type findingNote struct {
+	ips  []string
+	note lair.Note
+}
+
+// findingText describes a FINDING event for an issue or note.
+func findingText(description, module, rawURL string) string {
+	var b strings.Builder
+	b.WriteString(description)
+	b.WriteString("\n")
+	if rawURL != "" {
+		fmt.Fprintf(&b, "\nURL: %s", rawURL)
+	}
+	if module != "" {
+		fmt.Fprintf(&b, "\nReported by bbot module %s", module)
+	}
+	return sanitizeText(b.String())
+}
+
+// addFinding records a FINDING event according to -findings, as a low
+// severity issue or as a note for each host it refers to.
+func (im *importer) addFinding(entry map[string]interface{}, description, rawURL string) {
+	module, _ := entry["module"].(string)
+	id := shortHash(strings.ToLower(strings.TrimSpace(description)))
+	switch im.opts.findings {
+	case "issue":
+		issue := newIssue("finding-"+id, sanitizeText(vulnerabilityTitle(description)), findingCVSS, findingText(description, module, rawURL), "")
+		if module != "" {
+			issue.IdentifiedBy = append(issue.IdentifiedBy, lair.IdentifiedBy{Tool: "bbot " + module})
+		}
+		port := 0
+		if rawURL != "" {
+			port = urlPort(rawURL)
+			issue.Evidence = rawURL
+		}
+		issue.Hosts = issueHosts(entry, port)
+		if chain := im.provenance(entry); chain != "" {
+			issue.Evidence = strings.TrimSpace(issue.Evidence + "\n" + chain)
+		}
+		issue.Evidence = im.limitEvidence(issue.Evidence)
+		im.addIssue(issue)
+	case "note":
+		im.findingNotes = append(im.findingNotes, findingNote{
+			ips: eventIPs(entry),
+			note: lair.Note{
+				Title:          "drone-bbot: finding " + id,
+				Content:        findingText(description, module, rawURL),
+				LastModifiedBy: lastModifiedBy,
+			},
+		})
+	}
+}
+
+// applyFindingNotes adds the notes recorded for FINDING events to their hosts.
+// Hosts that are not in the project are skipped.
+func (im *importer) applyFindingNotes() {
+	for _, f := range im.findingNotes {
+		for _, ip := range f.ips {
+			im.updateHost(ip, func(host *lair.Host) bool {
+				if hasNote(host.Notes, f.note.Title) {
+					return false
+				}
+				host.Notes = append(host.Notes, f.note)
+				return true
+			})
+		}
+	}
+}
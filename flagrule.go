@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+var (
+	orPattern        = regexp.MustCompile(`(?i)\s+or\s+`)
+	andPattern       = regexp.MustCompile(`(?i)\s+and\s+`)
+	conditionPattern = regexp.MustCompile(`^([a-z][a-z.\-]*)\s*(>=|<=|!=|=|>|<)\s*(\S+)$`)
+)
+
+// flaggedTag is added, within the tag namespace, to the hosts a -flag-when rule
+// matches. Lair keeps the flagged bit of the hosts an import creates but not
+// of existing hosts, so the tag is what marks an existing host.
+const flaggedTag = "flagged"
+
+// condition compares a host field against a value.
+type condition struct {
+	field string
+	op    string
+	value string
+}
+
+// flagRule is an expression such as "port=3389 or hostname=*.dev.acme.com",
+// held as a disjunction of conjunctions of conditions. "and" binds tighter
+// than "or".
+type flagRule [][]condition
+
+// parseFlagRule parses a -flag-when expression. Supported fields are ip,
+// hostname, tag and service-tag, compared with = or != (shell style wildcards
+// allowed), and port and finding.severity, which also accept >, >=, < and <=.
+// Fields with several values, such as hostname, match when any value
+// satisfies the condition.
+func parseFlagRule(expr string) (flagRule, error) {
+	rule := flagRule{}
+	for _, clause := range orPattern.Split(strings.TrimSpace(expr), -1) {
+		conjunction := []condition{}
+		for _, term := range andPattern.Split(clause, -1) {
+			m := conditionPattern.FindStringSubmatch(strings.TrimSpace(term))
+			if m == nil {
+				return nil, fmt.Errorf("invalid condition %q", term)
+			}
+			c := condition{field: m[1], op: m[2], value: strings.ToLower(m[3])}
+			switch c.field {
+			case "ip", "hostname", "tag", "service-tag":
+				if c.op != "=" && c.op != "!=" {
+					return nil, fmt.Errorf("%s only supports = and !=", c.field)
+				}
+			case "port":
+				if _, err := strconv.Atoi(c.value); err != nil {
+					return nil, fmt.Errorf("invalid port %q", c.value)
+				}
+			case "finding.severity":
+				if _, ok := severityRank(c.value); !ok {
+					return nil, fmt.Errorf("invalid severity %q, expected one of %s", c.value, strings.Join(severities, ", "))
+				}
+			default:
+				return nil, fmt.Errorf("unknown field %q", c.field)
+			}
+			conjunction = append(conjunction, c)
+		}
+		rule = append(rule, conjunction)
+	}
+	return rule, nil
+}
+
+// matches reports whether host satisfies the rule. findings holds the rank in
+// severities of the most severe issue of each host by IP, as returned by
+// findingSeverities.
+func (r flagRule) matches(host lair.Host, findings map[string]int) bool {
+	for _, conjunction := range r {
+		all := true
+		for _, c := range conjunction {
+			if !c.matches(host, findings) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func (c condition) matches(host lair.Host, findings map[string]int) bool {
+	switch c.field {
+	case "ip":
+		return c.matchString([]string{host.IPv4})
+	case "hostname":
+		return c.matchString(host.Hostnames)
+	case "tag":
+		return c.matchString(host.Tags)
+	case "service-tag":
+		tags := []string{}
+		for _, service := range host.Services {
+			tags = append(tags, serviceTags(service)...)
+		}
+		return c.matchString(tags)
+	case "port":
+		want, _ := strconv.Atoi(c.value)
+		if c.op == "!=" {
+			for _, service := range host.Services {
+				if service.Port == want {
+					return false
+				}
+			}
+			return true
+		}
+		for _, service := range host.Services {
+			if compareInts(service.Port, c.op, want) {
+				return true
+			}
+		}
+	case "finding.severity":
+		rank, found := findings[host.IPv4]
+		want, _ := severityRank(c.value)
+		return found && compareInts(rank, c.op, want)
+	}
+	return false
+}
+
+// findingSeverities returns the rank in severities of the most severe of
+// issues affecting each host, keyed by IP. Hosts without issues have no
+// severity and match no finding.severity condition.
+func findingSeverities(issues ...[]lair.Issue) map[string]int {
+	ranks := make(map[string]int)
+	for _, list := range issues {
+		for _, issue := range list {
+			rank, _ := severityRank(issueSeverity(issue.CVSS))
+			for _, host := range issue.Hosts {
+				if current, found := ranks[host.IPv4]; !found || rank > current {
+					ranks[host.IPv4] = rank
+				}
+			}
+		}
+	}
+	return ranks
+}
+
+// matchString applies an = or != condition to values. != is satisfied when no
+// value matches.
+func (c condition) matchString(values []string) bool {
+	found := false
+	for _, v := range values {
+		if ok, _ := path.Match(c.value, strings.ToLower(v)); ok {
+			found = true
+			break
+		}
+	}
+	if c.op == "!=" {
+		return !found
+	}
+	return found
+}
+
+func compareInts(a int, op string, b int) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
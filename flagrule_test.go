@@ -0,0 +1,158 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lair-framework/go-lair"
+)
+
+func TestParseFlagRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    flagRule
+		wantErr bool
+	}{
+		{
+			name: "single condition",
+			expr: "port=3389",
+			want: flagRule{{{field: "port", op: "=", value: "3389"}}},
+		},
+		{
+			name: "and binds tighter than or",
+			expr: "port=3389 or hostname=*.dev.example.com and tag!=external",
+			want: flagRule{
+				{{field: "port", op: "=", value: "3389"}},
+				{{field: "hostname", op: "=", value: "*.dev.example.com"}, {field: "tag", op: "!=", value: "external"}},
+			},
+		},
+		{
+			name: "operators are case insensitive",
+			expr: "ip=10.0.0.* OR port>=8000 AND port<9000",
+			want: flagRule{
+				{{field: "ip", op: "=", value: "10.0.0.*"}},
+				{{field: "port", op: ">=", value: "8000"}, {field: "port", op: "<", value: "9000"}},
+			},
+		},
+		{
+			name: "values are lowercased",
+			expr: "hostname=WWW.Example.com",
+			want: flagRule{{{field: "hostname", op: "=", value: "www.example.com"}}},
+		},
+		{
+			name: "finding severity",
+			expr: "finding.severity>=HIGH",
+			want: flagRule{{{field: "finding.severity", op: ">=", value: "high"}}},
+		},
+		{name: "unknown field", expr: "os=linux", wantErr: true},
+		{name: "invalid port", expr: "port=rdp", wantErr: true},
+		{name: "ordering on a string field", expr: "hostname>a", wantErr: true},
+		{name: "invalid severity", expr: "finding.severity>=urgent", wantErr: true},
+		{name: "missing value", expr: "port=", wantErr: true},
+		{name: "empty clause", expr: "port=22 or", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFlagRule(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseFlagRule(%q) = %v, want an error", tt.expr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFlagRule(%q) returned %s", tt.expr, err.Error())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseFlagRule(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+			for i := range got {
+				if len(got[i]) != len(tt.want[i]) {
+					t.Fatalf("parseFlagRule(%q) = %v, want %v", tt.expr, got, tt.want)
+				}
+				for j := range got[i] {
+					if got[i][j] != tt.want[i][j] {
+						t.Errorf("parseFlagRule(%q) = %v, want %v", tt.expr, got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFlagRuleMatches(t *testing.T) {
+	web := lair.Host{
+		IPv4:      "10.0.0.1",
+		Hostnames: []string{"www.example.com", "app.dev.example.com"},
+		Tags:      []string{"external"},
+		Services:  []lair.Service{{Port: 80}, {Port: 443}},
+	}
+	rdp := lair.Host{
+		IPv4:     "10.0.1.5",
+		Services: []lair.Service{{Port: 3389}},
+	}
+	bare := lair.Host{IPv4: "192.0.2.1"}
+	findings := map[string]int{"10.0.0.1": 3, "10.0.1.5": 1}
+
+	tests := []struct {
+		expr string
+		host lair.Host
+		want bool
+	}{
+		{"port=3389", rdp, true},
+		{"port=3389", web, false},
+		{"port!=3389", web, true},
+		{"port!=3389", rdp, false},
+		{"port!=80", web, false},
+		{"port!=80", bare, true},
+		{"port>=443", web, true},
+		{"port<80", web, false},
+		{"ip=10.0.0.*", web, true},
+		{"ip=10.0.0.0/24", web, false},
+		{"hostname=*.dev.example.com", web, true},
+		{"hostname!=*.dev.example.com", web, false},
+		{"hostname=*.example.com", bare, false},
+		{"hostname!=*.example.com", bare, true},
+		{"tag=EXTERNAL", web, true},
+		{"tag!=external", rdp, true},
+		{"port=3389 or hostname=*.dev.example.com", web, true},
+		{"port=443 and tag!=external", web, false},
+		{"port=443 and tag=external or port=3389", rdp, true},
+		{"finding.severity>=high", web, true},
+		{"finding.severity>=high", rdp, false},
+		{"finding.severity=low", rdp, true},
+		{"finding.severity<=critical", bare, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" "+tt.host.IPv4, func(t *testing.T) {
+			rule, err := parseFlagRule(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.matches(tt.host, findings); got != tt.want {
+				t.Errorf("matches(%s) = %v, want %v", tt.host.IPv4, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindingSeverities(t *testing.T) {
+	imported := []lair.Issue{
+		{CVSS: 9.5, Hosts: []lair.IssueHost{{IPv4: "10.0.0.1"}}},
+		{CVSS: 2.5, Hosts: []lair.IssueHost{{IPv4: "10.0.0.1"}, {IPv4: "10.0.0.2"}}},
+	}
+	existing := []lair.Issue{
+		{CVSS: 5, Hosts: []lair.IssueHost{{IPv4: "10.0.0.2"}}},
+		{CVSS: 0, Hosts: []lair.IssueHost{{IPv4: "10.0.0.3"}}},
+	}
+	got := findingSeverities(imported, existing)
+	want := map[string]string{"10.0.0.1": "critical", "10.0.0.2": "medium", "10.0.0.3": "info"}
+	if len(got) != len(want) {
+		t.Fatalf("findingSeverities() = %v, want %v", got, want)
+	}
+	for ip, severity := range want {
+		if rank, _ := severityRank(severity); got[ip] != rank {
+			t.Errorf("findingSeverities()[%s] = %s, want %s", ip, severities[got[ip]], severity)
+		}
+	}
+}
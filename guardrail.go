@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// uniqueIPs returns the sorted, distinct IPs of hosts.
+func uniqueIPs(hosts []lair.Host) []string {
+	seen := make(map[string]bool)
+	ips := []string{}
+	for _, host := range hosts {
+		if !seen[host.IPv4] {
+			seen[host.IPv4] = true
+			ips = append(ips, host.IPv4)
+		}
+	}
+	sortIPs(ips)
+	return ips
+}
+
+// checkProjectSize returns the reason an import creating created hosts in a
+// project of existing hosts should be refused, or an empty string. Imports
+// that would more than double a non-empty project, or take it above max hosts
+// when max is non-zero, are refused.
+func checkProjectSize(existing, created, max int) string {
+	if max > 0 && existing+created > max {
+		return fmt.Sprintf("import would grow the project to %d hosts, above the limit of %d", existing+created, max)
+	}
+	if existing > 0 && created > existing {
+		return fmt.Sprintf("import would create %d hosts in a project of %d, more than doubling it", created, existing)
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// securityHeaderChecks are the headers reported by -header-issues when absent
+// from a web service's responses.
+var securityHeaderChecks = []struct {
+	pluginID    string
+	header      string
+	httpsOnly   bool
+	title       string
+	description string
+	solution    string
+}{
+	{
+		pluginID:    "missing-hsts",
+		header:      "strict-transport-security",
+		httpsOnly:   true,
+		title:       "Missing HTTP Strict Transport Security Header",
+		description: "The web service does not send a Strict-Transport-Security header, so browsers may connect over unencrypted HTTP and are exposed to SSL stripping attacks.",
+		solution:    "Send a Strict-Transport-Security header with a max-age of at least one year on all HTTPS responses.",
+	},
+	{
+		pluginID:    "missing-csp",
+		header:      "content-security-policy",
+		title:       "Missing Content Security Policy Header",
+		description: "The web service does not send a Content-Security-Policy header, which would limit the impact of cross-site scripting and content injection vulnerabilities.",
+		solution:    "Define a Content-Security-Policy that restricts the sources of scripts, styles and other content to those the application requires.",
+	},
+	{
+		pluginID:    "missing-x-frame-options",
+		header:      "x-frame-options",
+		title:       "Missing X-Frame-Options Header",
+		description: "The web service does not send an X-Frame-Options header or a Content-Security-Policy frame-ancestors directive, so its pages can be framed by other sites to perform clickjacking attacks.",
+		solution:    "Send X-Frame-Options: DENY or SAMEORIGIN, or a Content-Security-Policy frame-ancestors directive.",
+	},
+}
+
+// responseHeaders returns the headers of an HTTP_RESPONSE event keyed by lower
+// case, hyphenated name. bbot records them either as a header object with
+// underscored names or as the raw_header text.
+func responseHeaders(data map[string]interface{}) map[string]string {
+	headers := make(map[string]string)
+	if parsed, ok := data["header"].(map[string]interface{}); ok {
+		for name, value := range parsed {
+			name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+			headers[name] = fmt.Sprint(value)
+		}
+	}
+	if raw, ok := data["raw_header"].(string); ok {
+		for _, line := range strings.Split(raw, "\n") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			name := strings.ToLower(strings.TrimSpace(parts[0]))
+			if _, found := headers[name]; !found {
+				headers[name] = strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	return headers
+}
+
+// checkSecurityHeaders records an issue for each security header missing from
+// an HTTP response. Each web service is only checked once, using the first
+// response seen for it.
+func (im *importer) checkSecurityHeaders(entry map[string]interface{}, data map[string]interface{}, rawURL string) {
+	headers := responseHeaders(data)
+	if len(headers) == 0 {
+		return
+	}
+	port := urlPort(rawURL)
+	hosts := issueHosts(entry, port)
+	unchecked := hosts[:0]
+	for _, host := range hosts {
+		key := fmt.Sprintf("%s:%d", host.IPv4, host.Port)
+		if !im.headerChecked[key] {
+			im.headerChecked[key] = true
+			unchecked = append(unchecked, host)
+		}
+	}
+	if len(unchecked) == 0 {
+		return
+	}
+	https := strings.HasPrefix(strings.ToLower(rawURL), "https://")
+	csp := strings.ToLower(headers["content-security-policy"])
+	for _, check := range securityHeaderChecks {
+		if check.httpsOnly && !https {
+			continue
+		}
+		if _, found := headers[check.header]; found {
+			continue
+		}
+		if check.header == "x-frame-options" && strings.Contains(csp, "frame-ancestors") {
+			continue
+		}
+		issue := newIssue(check.pluginID, check.title, 0, check.description, check.solution)
+		issue.Hosts = unchecked
+		issue.Evidence = rawURL
+		im.addIssue(issue)
+	}
+}
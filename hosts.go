@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/lair-framework/go-lair"
+)
+
+// updateHost applies fn to the host with ip, whether it already exists in the
+// project or was created during this import. fn reports whether it modified
+// the host. updateHost reports whether a host with ip was found.
+func (im *importer) updateHost(ip string, fn func(host *lair.Host) bool) bool {
+	if host, found := im.existingIPs[ip]; found {
+		if fn(&host) {
+			host.LastModifiedBy = lastModifiedBy
+			im.existingIPs[ip] = host
+			im.updated[ip] = true
+		}
+		return true
+	}
+	for i := range im.project.Hosts {
+		if im.project.Hosts[i].IPv4 == ip {
+			fn(&im.project.Hosts[i])
+			return true
+		}
+	}
+	return false
+}
+
+// ensureService returns the service on host with port and protocol, adding it
+// if the host does not have one. name is used as the service name of a new
+// service.
+func ensureService(host *lair.Host, port int, protocol, name string) *lair.Service {
+	for i := range host.Services {
+		if host.Services[i].Port == port && host.Services[i].Protocol == protocol {
+			return &host.Services[i]
+		}
+	}
+	host.Services = append(host.Services, lair.Service{
+		Port:           port,
+		Protocol:       protocol,
+		Service:        name,
+		LastModifiedBy: lastModifiedBy,
+	})
+	return &host.Services[len(host.Services)-1]
+}
+
+// hasNote reports whether notes contains a note with title. Lair keeps only
+// the first note with a given title.
+func hasNote(notes []lair.Note, title string) bool {
+	for _, note := range notes {
+		if note.Title == title {
+			return true
+		}
+	}
+	return false
+}
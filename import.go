@@ -0,0 +1,762 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// summary describes the outcome of an import.
+type summary struct {
+	Project      string              `json:"project"`
+	Imported     bool                `json:"imported"`
+	HostsCreated int                 `json:"hostsCreated"`
+	HostsUpdated int                 `json:"hostsUpdated"`
+	Created      []string            `json:"created"`
+	Updated      []string            `json:"updated"`
+	NotFound     map[string][]string `json:"notFound"`
+	Changed      bool                `json:"changed"`
+	Refused      string              `json:"refused,omitempty"`
+	Partial      bool                `json:"partial"`
+	Tickets      int                 `json:"tickets"`
+	Locked       map[string][]string `json:"locked,omitempty"`
+	Removed      map[string][]string `json:"removed,omitempty"`
+	QA           *qaReport           `json:"qa,omitempty"`
+	Screenshots  int                 `json:"screenshots"`
+	KnownAbsent  int                 `json:"knownAbsent"`
+	Metrics      *metrics            `json:"metrics"`
+}
+
+// importer holds the state of a single import while bbot events are processed.
+type importer struct {
+	opts             *options
+	project          *lair.Project
+	existingIPs      map[string]lair.Host
+	updated          map[string]bool
+	bNotFound        map[string][]string
+	bInScope         map[string]bool
+	names            map[string][]string
+	changes          []resolutionChange
+	authInterfaces   map[string]bool
+	urls             map[string]bool
+	issueIndex       map[string]int
+	webPaths         map[string]*webPaths
+	headerChecked    map[string]bool
+	seen             map[string]map[string]bool
+	cpes             map[string]*cpeSet
+	projectIPv6      bool
+	ipv6Only         map[string][]string
+	ipv6Skipped      []string
+	serviceTags      map[string]*serviceTagSet
+	openPorts        map[string]map[int]bool
+	speculatedPorts  map[string]map[int]bool
+	findingNotes     []findingNote
+	technologies     map[string]*technologySet
+	webDirectories   map[string]*webDirectory
+	httpBanners      map[string]*httpBanner
+	origins          map[string]eventOrigin
+	emails           map[string]map[string]bool
+	netblocks        map[string]*lair.Netblock
+	certificates     map[string]*certificate
+	wafs             map[string]*wafDetection
+	targets          map[string]bool
+	vhosts           map[string]map[string]string
+	protocols        map[string]*serviceProtocol
+	osint            map[string]string
+	people           map[string]lair.Person
+	cloud            map[string]map[string]bool
+	azureTenants     map[string]bool
+	webParameters    map[string]*webParameters
+	screenshots      map[string]*screenshot
+	metrics          *metrics
+	unresolved       map[string]string
+	removedSightings map[string][]string
+	takeovers        []takeover
+	cnames           map[string]string
+	dnsHistory       map[string]string
+	lineage          map[string]eventLineage
+	scanMeta         *scanMeta
+}
+
+// run parses the bbot events in r and imports the result into the Lair
+// project lairPID.
+func run(c *client.C, opts *options, lairPID string, r io.Reader) (*summary, error) {
+	existingProject, err := c.ExportProject(lairPID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to export project: %s", err.Error())
+	}
+
+	im := &importer{
+		opts: opts,
+		project: &lair.Project{
+			ID:   lairPID,
+			Tool: lastModifiedBy,
+			Commands: []lair.Command{
+				{Tool: tool, Command: opts.commandLabel},
+			},
+		},
+		existingIPs:      make(map[string]lair.Host),
+		updated:          make(map[string]bool),
+		bNotFound:        make(map[string][]string),
+		bInScope:         make(map[string]bool),
+		names:            hostnameIndex(existingProject.Hosts),
+		authInterfaces:   make(map[string]bool),
+		urls:             make(map[string]bool),
+		issueIndex:       make(map[string]int),
+		webPaths:         make(map[string]*webPaths),
+		headerChecked:    make(map[string]bool),
+		seen:             make(map[string]map[string]bool),
+		cpes:             make(map[string]*cpeSet),
+		technologies:     make(map[string]*technologySet),
+		webDirectories:   make(map[string]*webDirectory),
+		httpBanners:      make(map[string]*httpBanner),
+		origins:          make(map[string]eventOrigin),
+		emails:           make(map[string]map[string]bool),
+		netblocks:        make(map[string]*lair.Netblock),
+		certificates:     make(map[string]*certificate),
+		wafs:             make(map[string]*wafDetection),
+		targets:          make(map[string]bool),
+		vhosts:           make(map[string]map[string]string),
+		protocols:        make(map[string]*serviceProtocol),
+		osint:            make(map[string]string),
+		people:           make(map[string]lair.Person),
+		cloud:            make(map[string]map[string]bool),
+		azureTenants:     make(map[string]bool),
+		webParameters:    make(map[string]*webParameters),
+		screenshots:      make(map[string]*screenshot),
+		metrics:          newMetrics(),
+		unresolved:       make(map[string]string),
+		removedSightings: make(map[string][]string),
+		cnames:           make(map[string]string),
+		dnsHistory:       make(map[string]string),
+		lineage:          make(map[string]eventLineage),
+		scanMeta:         opts.scanPreset.clone(),
+		projectIPv6:      hasIPv6Hosts(existingProject.Hosts),
+		ipv6Only:         make(map[string][]string),
+		serviceTags:      make(map[string]*serviceTagSet),
+		openPorts:        make(map[string]map[int]bool),
+		speculatedPorts:  make(map[string]map[int]bool),
+	}
+	project := im.project
+	for _, host := range existingProject.Hosts {
+		im.existingIPs[host.IPv4] = host
+	}
+	for _, ai := range existingProject.AuthInterfaces {
+		im.authInterfaces[ai.URL] = true
+	}
+
+	skip := 0
+	if opts.checkpoint != "" {
+		if skip, err = readCheckpoint(opts.checkpoint); err != nil {
+			return nil, fmt.Errorf("unable to read checkpoint: %s", err.Error())
+		}
+		if skip > 0 {
+			logf("Resuming from checkpoint, skipping the first %d lines", skip)
+		}
+	}
+	var deadline time.Time
+	if opts.maxDuration > 0 {
+		deadline = time.Now().Add(opts.maxDuration)
+	}
+	partial := false
+	consumed := 0
+
+	readStart := time.Now()
+	inputHash := sha256.New()
+	scanner := newEventScanner(io.TeeReader(r, inputHash))
+	for scanner.Scan() {
+		if consumed < skip {
+			consumed++
+			continue
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			partial = true
+			break
+		}
+		consumed++
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if opts.onlyTypes != nil && !mentionsEventType(line, opts.onlyTypes) {
+			continue
+		}
+
+		var entry map[string]interface{}
+		err = json.Unmarshal(line, &entry)
+		recordEvent(consumed, line, entry, err)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse bbot JSON: %s", err.Error())
+		}
+		im.handle(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read bbot JSON: %s", err.Error())
+	}
+	readDone := time.Now()
+	im.metrics.ReadSeconds = readDone.Sub(readStart).Seconds()
+
+	if opts.exportBurp != "" {
+		if err := writeBurpExport(opts.exportBurp, im.urls); err != nil {
+			return nil, fmt.Errorf("unable to write Burp export: %s", err.Error())
+		}
+		logf("Wrote %d URLs to %s and %s", len(im.urls), opts.exportBurp, urlListPath(opts.exportBurp))
+	}
+
+	if opts.probeUnmatched && len(im.bNotFound) > 0 {
+		unmatched := im.probeCandidates()
+		if skipped := len(im.bNotFound) - len(unmatched); skipped > 0 {
+			logf("Skipped probing %d hosts that do not exist in lair and are outside of the scope", skipped)
+		}
+		logf("Probing %d hosts that do not exist in lair", len(unmatched))
+		for ip, openPorts := range probeHosts(unmatched, opts.ports, opts.probeRate, opts.probeTimeout) {
+			host := lair.Host{
+				IPv4:           ip,
+				Hostnames:      im.bNotFound[ip],
+				Tags:           opts.hostTags,
+				LastModifiedBy: lastModifiedBy,
+			}
+			if opts.lowConfidence > 0 {
+				host.Tags = opts.lowConfidenceTags()
+				host.Status = lair.StatusGrey
+			}
+			for _, port := range openPorts {
+				host.Services = append(host.Services, lair.Service{
+					Port:           port,
+					Protocol:       "tcp",
+					LastModifiedBy: lastModifiedBy,
+				})
+			}
+			project.Hosts = append(project.Hosts, host)
+			delete(im.bNotFound, ip)
+		}
+	}
+
+	if opts.enricher != nil && len(im.bNotFound) > 0 {
+		if err := im.enrichUnmatched(); err != nil {
+			return nil, fmt.Errorf("unable to enrich hosts: %s", err.Error())
+		}
+	}
+
+	im.applyOpenPorts()
+	im.applySpeculatedPorts()
+	im.applyProtocols()
+	im.applyScopeGuard()
+
+	if opts.lowConfidence > 0 && !opts.forceHosts {
+		created := 0
+		for ip, names := range im.bNotFound {
+			names, _ = appendUnique(nil, names...)
+			if len(names) < opts.lowConfidence {
+				continue
+			}
+			project.Hosts = append(project.Hosts, lair.Host{
+				IPv4:           ip,
+				Hostnames:      names,
+				Tags:           opts.lowConfidenceTags(),
+				Status:         lair.StatusGrey,
+				LastModifiedBy: lastModifiedBy,
+			})
+			delete(im.bNotFound, ip)
+			created++
+		}
+		if created > 0 {
+			logf("Created %d low-confidence hosts with at least %d DNS names", created, opts.lowConfidence)
+		}
+	}
+	im.skipCloudHosts()
+
+	if opts.maxHostsPerDomain > 0 {
+		var overflow map[string][]string
+		project.Hosts, overflow = applyDomainQuota(project.Hosts, opts.maxHostsPerDomain)
+		if len(overflow) > 0 {
+			project.Notes = append(project.Notes, quotaNote(overflow, opts.maxHostsPerDomain))
+			logf("Skipped hosts for %d domains that exceeded -max-hosts-per-domain", len(overflow))
+		}
+	}
+
+	if notes := im.emailNotes(existingProject.Notes); len(notes) > 0 {
+		project.Notes = append(project.Notes, notes...)
+		logf("Recorded email addresses for %d domains", len(notes))
+	}
+	project.Notes = append(project.Notes, im.osintNotes(existingProject.Notes)...)
+	im.applyUnresolved(existingProject.Notes)
+	im.applyPeople(existingProject.People)
+
+	if opts.migrateTags {
+		if legacy := im.migrateTags(); len(legacy) > 0 {
+			logf("Added %q prefixed copies of legacy tags, remove the originals in Lair: %s", opts.tagNamespace, strings.Join(legacy, ", "))
+		}
+	}
+
+	now := time.Now()
+	for _, change := range im.changes {
+		for _, ip := range append([]string{change.oldIP}, change.newIPs...) {
+			im.updateHost(ip, func(host *lair.Host) bool {
+				return addResolutionNote(host, change, now)
+			})
+		}
+	}
+
+	im.applyIPv6Notes()
+	if len(im.ipv6Skipped) > 0 {
+		sort.Strings(im.ipv6Skipped)
+		logf("Skipped %d DNS names that only resolve to IPv6, see -ipv6-policy: %s", len(im.ipv6Skipped), strings.Join(im.ipv6Skipped, ", "))
+	}
+	im.applyWebPaths()
+	im.applyWebParameters()
+	im.applyScreenshots()
+	im.applyWebDirectories()
+	im.applyHTTPBanners()
+	im.applyCertificates()
+	im.applyWAFs()
+	im.applyCloudTags()
+	im.applyVHosts()
+	im.applyCPEs()
+	im.applyTechnologies()
+	im.applyServiceTags()
+	im.applyFindingNotes()
+	if opts.passiveDNS != nil {
+		if err := im.applyPassiveDNS(existingProject.Hosts, now); err != nil {
+			return nil, fmt.Errorf("unable to look up passive DNS history: %s", err.Error())
+		}
+	}
+	im.applyTakeovers()
+	im.applyNetblocks()
+	if partial || skip > 0 || (opts.onlyTypes != nil && !opts.onlyTypes["DNS_NAME"]) || opts.onlyModules != nil {
+		if opts.trackSeen {
+			logf("Skipped -track-seen, this run did not read every DNS_NAME event of the input")
+		}
+	} else {
+		im.applySeen(now)
+	}
+	if opts.scanDir != "" {
+		artifacts, err := scanArtifacts(opts.scanDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read scan directory: %s", err.Error())
+		}
+		im.applyArtifacts(artifacts)
+	}
+
+	if opts.minSeverityRank > 0 {
+		var skipped []lair.Issue
+		project.Issues, skipped = filterIssues(project.Issues, opts.minSeverityRank)
+		if len(skipped) > 0 && opts.skippedIssuesFile != "" {
+			if err := writeIssues(opts.skippedIssuesFile, skipped); err != nil {
+				return nil, fmt.Errorf("unable to write skipped issues: %s", err.Error())
+			}
+			logf("Wrote %d issues below -min-severity to %s", len(skipped), opts.skippedIssuesFile)
+		}
+	}
+
+	im.applyHostDisplay()
+	project.Commands[0].Command = commandText(opts.commandLabel, im.scanMeta)
+	if opts.flagRule != nil {
+		findings := findingSeverities(project.Issues, existingProject.Issues)
+		tag := opts.namespaceTag(flaggedTag)
+		for i := range project.Hosts {
+			if opts.flagRule.matches(project.Hosts[i], findings) {
+				project.Hosts[i].IsFlagged = true
+				project.Hosts[i].Tags, _ = appendUnique(project.Hosts[i].Tags, tag)
+			}
+		}
+		for ip, host := range im.existingIPs {
+			if !opts.flagRule.matches(host, findings) {
+				continue
+			}
+			var added bool
+			if host.Tags, added = appendUnique(host.Tags, tag); added {
+				host.LastModifiedBy = lastModifiedBy
+				im.existingIPs[ip] = host
+				im.updated[ip] = true
+			}
+		}
+	}
+
+	knownAbsent := 0
+	if opts.absent != nil {
+		knownAbsent = opts.absent.filter(lairPID, existingProject.Hosts, im.bNotFound)
+	}
+	if opts.notFoundNote && len(im.bNotFound) > 0 {
+		project.Notes = append(project.Notes, notFoundNote(im.bNotFound, now))
+	}
+	im.applyTagExpiry(now)
+	removed := im.applyRemovedHosts(existingProject.Hosts)
+	locked := im.applyLockedHosts(existingProject.Hosts)
+	if opts.additiveOnly {
+		im.applyAdditiveOnly(&existingProject)
+	}
+
+	s := &summary{
+		Project:     lairPID,
+		Partial:     partial,
+		Created:     uniqueIPs(project.Hosts),
+		Updated:     []string{},
+		NotFound:    im.bNotFound,
+		Locked:      locked,
+		Removed:     removed,
+		Metrics:     im.metrics,
+		KnownAbsent: knownAbsent,
+	}
+	for ip := range im.updated {
+		s.Updated = append(s.Updated, ip)
+	}
+	sortIPs(s.Updated)
+	s.HostsCreated = len(s.Created)
+	s.HostsUpdated = len(s.Updated)
+	s.Changed = s.HostsCreated > 0 || s.HostsUpdated > 0 || len(project.Notes) > 0 ||
+		len(project.AuthInterfaces) > 0 || len(project.Issues) > 0 || len(project.Netblocks) > 0 || len(project.People) > 0
+	if opts.detectChanges {
+		return s, nil
+	}
+	if !opts.force {
+		s.Refused = checkProjectSize(len(existingProject.Hosts), s.HostsCreated, opts.maxProjectHosts)
+		if s.Refused != "" {
+			return s, nil
+		}
+	}
+
+	var names []qaName
+	if opts.qaSample > 0 {
+		names = im.importedNames(project.Hosts, existingProject.Hosts)
+	}
+	for ip, host := range im.existingIPs {
+		if im.updated[ip] {
+			project.Hosts = append(project.Hosts, host)
+		}
+	}
+
+	if opts.tui && (len(project.Hosts) > 0 || len(project.Issues) > 0) {
+		var proceed bool
+		project.Hosts, project.Issues, proceed = reviewImport(os.Stdin, os.Stdout, project.Hosts, project.Issues)
+		if !proceed {
+			return nil, fmt.Errorf("import cancelled during review")
+		}
+	}
+	for ip, host := range im.existingIPs {
+		if !im.updated[ip] {
+			project.Hosts = append(project.Hosts, host)
+		}
+	}
+
+	if len(project.Hosts) > 0 || len(project.Notes) > 0 || len(project.AuthInterfaces) > 0 || len(project.Issues) > 0 || len(project.Netblocks) > 0 || len(project.People) > 0 {
+		if err := importProject(c, project, opts.maxPayloadMB<<20, opts.batchState, hex.EncodeToString(inputHash.Sum(nil))); err != nil {
+			return nil, fmt.Errorf("unable to import project: %s", err.Error())
+		}
+		s.Imported = true
+	}
+
+	if opts.qaSample > 0 && s.Imported {
+		s.QA = verifySample(names, opts.qaSample)
+	}
+
+	if opts.screenshots == "upload" && s.Imported && len(im.screenshots) > 0 {
+		if s.Screenshots, err = im.uploadScreenshots(c, lairPID); err != nil {
+			return nil, fmt.Errorf("unable to upload screenshots: %s", err.Error())
+		}
+		logf("Uploaded %d screenshots to Lair", s.Screenshots)
+	}
+
+	if opts.checkpoint != "" {
+		if !partial {
+			consumed = 0
+		}
+		if err := writeCheckpoint(opts.checkpoint, consumed); err != nil {
+			return nil, fmt.Errorf("unable to write checkpoint: %s", err.Error())
+		}
+	}
+
+	if opts.ticketer != nil && s.Imported {
+		for _, issue := range newHighIssues(existingProject.Issues, project.Issues) {
+			if err := opts.ticketer.open(lairPID, issue); err != nil {
+				logf("Error: Unable to open a ticket for %s. Error %s", issue.Title, err.Error())
+				continue
+			}
+			s.Tickets++
+		}
+	}
+	im.metrics.finish(readDone)
+	return s, nil
+}
+
+// importedEventTypes are the bbot event types handle imports.
+var importedEventTypes = []string{"DNS_NAME", "URL", "URL_UNVERIFIED", "TECHNOLOGY", "HTTP_RESPONSE", "FINDING", "RAW_DNS_RECORD", "OPEN_TCP_PORT", "VULNERABILITY", "EMAIL_ADDRESS", "ASN", "IP_RANGE", "STORAGE_BUCKET", "WAF", "VHOST", "PROTOCOL", "CODE_REPOSITORY", "SOCIAL", "WHOIS", "RDAP", "AZURE_TENANT", "WEB_PARAMETER", "WEBSCREENSHOT", "DNS_NAME_UNRESOLVED", "IP_ADDRESS"}
+
+// isImportedEventType reports whether events of eventType are imported.
+func isImportedEventType(eventType string) bool {
+	for _, t := range importedEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// mentionsEventType reports whether line could be an event of one of types. It
+// lets -only skip decoding events that can not match.
+func mentionsEventType(line []byte, types map[string]bool) bool {
+	for t := range types {
+		if bytes.Contains(line, []byte(`"`+t+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handle dispatches a bbot event to the handler for its type. Events excluded
+// by -only or -modules are ignored apart from recording their origin for
+// -provenance and bbot's targets. The cloud provider tags of every other event
+// are recorded, whether or not its type is imported.
+func (im *importer) handle(entry map[string]interface{}) {
+	im.recordOrigin(entry)
+	im.recordLineage(entry)
+	im.recordTarget(entry)
+	im.recordScan(entry)
+	im.metrics.recordTimestamp(entry)
+	eventType, _ := entry["type"].(string)
+	module, _ := entry["module"].(string)
+	if (im.opts.onlyTypes != nil && !im.opts.onlyTypes[eventType]) || (im.opts.onlyModules != nil && !im.opts.onlyModules[strings.ToLower(module)]) {
+		im.metrics.Excluded++
+		return
+	}
+	im.recordCloud(entry)
+	if isImportedEventType(eventType) {
+		im.metrics.handler(eventType).Processed++
+	}
+	switch entry["type"] {
+	case "DNS_NAME":
+		im.handleDNSName(entry)
+	case "URL":
+		im.handleURL(entry)
+	case "URL_UNVERIFIED":
+		im.handleURLUnverified(entry)
+	case "TECHNOLOGY":
+		im.handleTechnology(entry)
+	case "HTTP_RESPONSE":
+		im.handleHTTPResponse(entry)
+	case "FINDING":
+		im.handleFinding(entry)
+	case "RAW_DNS_RECORD":
+		im.handleRawDNSRecord(entry)
+	case "OPEN_TCP_PORT":
+		im.handleOpenTCPPort(entry)
+	case "VULNERABILITY":
+		im.handleVulnerability(entry)
+	case "EMAIL_ADDRESS":
+		im.handleEmailAddress(entry)
+	case "ASN":
+		im.handleASN(entry)
+	case "IP_RANGE":
+		im.handleIPRange(entry)
+	case "STORAGE_BUCKET":
+		im.handleStorageBucket(entry)
+	case "WAF":
+		im.handleWAF(entry)
+	case "VHOST":
+		im.handleVHost(entry)
+	case "PROTOCOL":
+		im.handleProtocol(entry)
+	case "CODE_REPOSITORY", "SOCIAL":
+		im.handleOSINT(entry)
+	case "WHOIS", "RDAP":
+		im.handleRegistration(entry)
+	case "WEB_PARAMETER":
+		im.handleWebParameter(entry)
+	case "WEBSCREENSHOT":
+		im.handleWebScreenshot(entry)
+	case "DNS_NAME_UNRESOLVED":
+		im.handleDNSNameUnresolved(entry)
+	case "IP_ADDRESS":
+		im.handleIPAddress(entry)
+	}
+}
+
+// handleDNSName attaches the hostname, and the names in its dns_children, to
+// every host it resolved to.
+func (im *importer) handleDNSName(entry map[string]interface{}) {
+	im.importDNSName(entry, 0)
+}
+
+// importDNSName imports a DNS_NAME event, or one nested depth levels deep in
+// the dns_children of another.
+func (im *importer) importDNSName(entry map[string]interface{}, depth int) {
+	host, _ := entry["host"].(string)
+	if host == "" {
+		return
+	}
+	dnsName := sanitizeText(host)
+	im.recordCNAMEs(entry)
+	resolved := resolvedHosts(entry)
+	if len(resolved) > 0 {
+		im.changes = append(im.changes, resolutionChanges(im.names, dnsName, resolved)...)
+	}
+	defer im.handleDNSChildren(entry, resolved, depth)
+	if len(im.opts.txtRules) > 0 {
+		im.checkTXTRecords(entry, dnsName, txtChildren(entry))
+	}
+	matched := false
+	if depth == 0 {
+		defer func() {
+			if stats := im.metrics.handler("DNS_NAME"); matched {
+				stats.Matched++
+			} else {
+				stats.Skipped++
+			}
+		}()
+	}
+	if !im.projectIPv6 && onlyIPv6(resolved) {
+		im.handleIPv6Only(dnsName, resolved)
+		return
+	}
+	for _, ipStr := range resolved {
+		if existingHost, found := im.existingIPs[ipStr]; found {
+			matched = true
+			if mergeHost(&existingHost, []string{dnsName}, im.opts.hostTags) {
+				im.updated[ipStr] = true
+			}
+			im.existingIPs[ipStr] = existingHost
+			im.recordSeen(ipStr, dnsName)
+			im.recordRemovedSighting(existingHost, dnsName)
+		} else {
+			if im.opts.forceHosts {
+				matched = true
+				im.project.Hosts = append(im.project.Hosts, lair.Host{
+					IPv4:           ipStr,
+					Hostnames:      []string{dnsName},
+					Tags:           im.opts.hostTags,
+					LastModifiedBy: lastModifiedBy,
+				})
+				im.recordSeen(ipStr, dnsName)
+			} else {
+				im.bNotFound[ipStr] = append(im.bNotFound[ipStr], dnsName)
+				if depth == 0 && !outsideScope(entry) {
+					im.bInScope[ipStr] = true
+				}
+			}
+		}
+	}
+}
+
+// handleURL records URLs for export and as web directories, and those that
+// point at login portals as auth interfaces.
+func (im *importer) handleURL(entry map[string]interface{}) {
+	rawURL, _ := entry["data"].(string)
+	im.recordURL(rawURL)
+	im.recordWebPath(entry, rawURL)
+	im.recordWebDirectory(entry, rawURL)
+	im.recordServiceTags(entry, rawURL)
+	if !im.opts.importAuthInterfaces {
+		return
+	}
+	if kind := authKindForURL(rawURL); kind != "" {
+		module, _ := entry["module"].(string)
+		im.addAuthInterface(kind, rawURL, module)
+	}
+}
+
+// handleURLUnverified records paths found in robots.txt and sitemaps, and the
+// path as a web directory. bbot emits these as URL_UNVERIFIED until they have
+// been visited.
+func (im *importer) handleURLUnverified(entry map[string]interface{}) {
+	rawURL, _ := entry["data"].(string)
+	im.recordWebPath(entry, rawURL)
+	im.recordWebDirectory(entry, rawURL)
+}
+
+// handleHTTPResponse records the URL of each HTTP response for export and the
+// banner and certificate of its web service, and reports directory listings
+// and, with -header-issues, missing security headers as issues.
+func (im *importer) handleHTTPResponse(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	rawURL, _ := data["url"].(string)
+	im.recordURL(rawURL)
+	if rawURL == "" {
+		return
+	}
+	im.recordServiceTags(entry, rawURL)
+	im.recordHTTPBanner(entry, data, rawURL)
+	im.recordCertificate(entry, data, rawURL)
+	if title, _ := data["title"].(string); isDirectoryListingTitle(title) {
+		im.addDirectoryListing(entry, rawURL)
+	}
+	if im.opts.headerIssues {
+		im.checkSecurityHeaders(entry, data, rawURL)
+	}
+}
+
+// handleFinding reports FINDING events that describe subdomain takeover
+// candidates or directory listings as issues, and other findings as issues or
+// host notes with -findings.
+func (im *importer) handleFinding(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	description, _ := data["description"].(string)
+	rawURL, _ := data["url"].(string)
+	if isTakeover(entry, description) {
+		im.recordTakeover(entry, description, 0)
+		return
+	}
+	if rawURL != "" && isDirectoryListingFinding(description) {
+		im.addDirectoryListing(entry, rawURL)
+		return
+	}
+	if description != "" {
+		im.addFinding(entry, description, rawURL)
+	}
+}
+
+// resolvedHosts returns the IPs listed in an event's resolved_hosts, or in
+// the A and AAAA records of its dns_children.
+func resolvedHosts(entry map[string]interface{}) []string {
+	raw, _ := entry["resolved_hosts"].([]interface{})
+	resolved := []string{}
+	for _, ip := range raw {
+		if ipStr, ok := ip.(string); ok {
+			resolved = append(resolved, ipStr)
+		}
+	}
+	resolved, _ = appendUnique(resolved, childIPs(entry)...)
+	return resolved
+}
+
+// mergeHost adds the hostnames and tags that host does not already have,
+// reporting whether host was modified.
+func mergeHost(host *lair.Host, hostnames, tags []string) bool {
+	var newNames, newTags bool
+	host.Hostnames, newNames = appendUnique(host.Hostnames, hostnames...)
+	host.Tags, newTags = appendUnique(host.Tags, tags...)
+	if newNames || newTags {
+		host.LastModifiedBy = lastModifiedBy
+		return true
+	}
+	return false
+}
+
+// appendUnique appends the items that are not already in list, and reports
+// whether any were added.
+func appendUnique(list []string, items ...string) ([]string, bool) {
+	added := false
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+			added = true
+		}
+	}
+	return list, added
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxEventSize is the largest event line read, enough for the HTTP_RESPONSE
+// events bbot writes with the full response body.
+const maxEventSize = 64 << 20
+
+// scanOutputFiles are the event files bbot writes to a scan directory, in
+// order of preference.
+var scanOutputFiles = []string{"output.ndjson", "output.json", "output.json.gz", "output.csv", "output.sqlite"}
+
+// inputFormats are the values accepted by -format. The empty format detects
+// the format of the input.
+var inputFormats = []string{"", "ndjson", "json", "csv", "sqlite"}
+
+// input is an opened bbot output, converted to NDJSON events.
+type input struct {
+	io.Reader
+	format  string
+	closers []io.Closer
+}
+
+func (in *input) Close() error {
+	var err error
+	for i := len(in.closers) - 1; i >= 0; i-- {
+		if cerr := in.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// openInput opens path, which may be an event file, a bbot scan directory or
+// "-" for standard input, and detects its format unless format is set. filter
+// selects the events read from a bbot SQLite database.
+func openInput(path, format string, filter eventFilter) (*input, error) {
+	if path == "-" {
+		return detectInput(os.Stdin, format, filter)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		found := ""
+		for _, name := range scanOutputFiles {
+			if format != "" && !strings.HasPrefix(name, "output."+format) {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				found = filepath.Join(path, name)
+				break
+			}
+		}
+		if found == "" && format == "" {
+			if f, err := os.Open(filepath.Join(path, assetInventoryFile)); err == nil {
+				return &input{Reader: convertInput(f, assetInventoryEvents), format: "scan directory, bbot asset inventory", closers: []io.Closer{f}}, nil
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("%s is a directory without a bbot output file (%s)", path, strings.Join(append(scanOutputFiles, assetInventoryFile), ", "))
+		}
+		in, err := openInput(found, format, filter)
+		if err != nil {
+			return nil, err
+		}
+		in.format = "scan directory, " + in.format
+		return in, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	in, err := detectInput(f, format, filter)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	in.closers = append([]io.Closer{f}, in.closers...)
+	return in, nil
+}
+
+// expandInputs returns the files named by the <filename> arguments, expanding
+// glob patterns such as scans/*.ndjson for shells that do not. Files named
+// more than once are read once.
+func expandInputs(args []string) ([]string, error) {
+	files := []string{}
+	seen := map[string]bool{}
+	for _, arg := range args {
+		matches := []string{arg}
+		if arg != "-" && strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %s", arg, err.Error())
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no file matches %s", arg)
+			}
+		}
+		for _, file := range matches {
+			if !seen[filepath.Clean(file)] {
+				seen[filepath.Clean(file)] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// isInputArg reports whether arg names an input rather than a Lair project
+// id: - for stdin, an existing file or directory, or a glob pattern.
+func isInputArg(arg string) bool {
+	if arg == "-" || strings.ContainsAny(arg, "*?[") {
+		return true
+	}
+	_, err := os.Stat(arg)
+	return err == nil
+}
+
+// joinInputs reads the events of several inputs one after the other, as a
+// single import.
+func joinInputs(ins []*input) *input {
+	joined := &input{format: fmt.Sprintf("%d files", len(ins))}
+	readers := []io.Reader{}
+	for i, in := range ins {
+		if i > 0 {
+			readers = append(readers, strings.NewReader("\n"))
+		}
+		readers = append(readers, in)
+		joined.closers = append(joined.closers, in)
+	}
+	joined.Reader = io.MultiReader(readers...)
+	return joined
+}
+
+// detectInput inspects the start of r and returns it as NDJSON events. bbot
+// NDJSON, indented JSON events, JSON arrays of events, bbot CSV, bbot SQLite
+// databases and gzip compressed forms of these are supported. The events of a
+// SQLite database are selected by filter. A format other than "" skips the
+// detection of the uncompressed format.
+func detectInput(r io.Reader, format string, filter eventFilter) (*input, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(16)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip input: %s", err.Error())
+		}
+		in, err := detectInput(zr, format, filter)
+		if err != nil {
+			zr.Close()
+			return nil, err
+		}
+		in.format = "gzip compressed " + in.format
+		in.closers = append([]io.Closer{zr}, in.closers...)
+		return in, nil
+	case bytes.HasPrefix(head, []byte("SQLite format 3\x00")):
+		return sqliteInput(r, br, filter)
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return nil, errors.New("zip archives are not supported, extract the bbot output file first")
+	}
+
+	if format == "sqlite" {
+		return nil, errors.New("not a SQLite database")
+	}
+	if format == "csv" {
+		return &input{Reader: convertInput(br, csvEvents), format: "bbot CSV"}, nil
+	}
+	first, err := firstByte(br)
+	if err != nil {
+		return nil, err
+	}
+	if format == "ndjson" {
+		return &input{Reader: br, format: "bbot NDJSON"}, nil
+	}
+	if format == "json" && first != '[' {
+		return &input{Reader: convertInput(br, jsonStreamEvents), format: "indented bbot JSON"}, nil
+	}
+	switch first {
+	case '{':
+		if isJSONStream(br) {
+			return &input{Reader: convertInput(br, jsonStreamEvents), format: "indented bbot JSON"}, nil
+		}
+		return &input{Reader: br, format: "bbot NDJSON"}, nil
+	case 0:
+		return &input{Reader: br, format: "bbot NDJSON"}, nil
+	case '[':
+		return &input{Reader: convertInput(br, jsonArrayEvents), format: "bbot JSON array"}, nil
+	}
+	line, _ := br.Peek(512)
+	if header := strings.ToLower(string(line)); strings.Contains(header, "event type") && strings.Contains(header, "event data") {
+		return &input{Reader: convertInput(br, csvEvents), format: "bbot CSV"}, nil
+	}
+	return nil, errors.New("unrecognized input, expected bbot NDJSON, indented JSON events, a JSON array of events, bbot CSV, a bbot SQLite database, a gzip file of one of these or a scan directory")
+}
+
+// firstByte returns the first byte of br that is not whitespace or part of a
+// UTF-8 byte order mark, without consuming it, or 0 for empty input.
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n', 0xef, 0xbb, 0xbf:
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// isJSONStream reports whether the events in br are indented JSON objects
+// rather than one object per line, which is the case when the first line
+// holds only the opening brace.
+func isJSONStream(br *bufio.Reader) bool {
+	head, _ := br.Peek(br.Size())
+	line, _, found := bytes.Cut(head, []byte("\n"))
+	return found && bytes.Equal(bytes.TrimSpace(line), []byte("{"))
+}
+
+// newEventScanner returns a scanner for the lines of NDJSON events in r that
+// accepts lines of up to maxEventSize bytes.
+func newEventScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+	return scanner
+}
+
+// convertInput streams the events produced by convert as NDJSON lines.
+func convertInput(r io.Reader, convert func(io.Reader, func(map[string]interface{}) error) error) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		enc := json.NewEncoder(pw)
+		pw.CloseWithError(convert(r, func(event map[string]interface{}) error {
+			return enc.Encode(event)
+		}))
+	}()
+	return pr
+}
+
+// jsonArrayEvents emits each element of a JSON array of events. Elements are
+// decoded one at a time, so the array is never held in memory.
+func jsonArrayEvents(r io.Reader, emit func(map[string]interface{}) error) error {
+	dec := json.NewDecoder(r)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for n := 1; dec.More(); n++ {
+		var event map[string]interface{}
+		if err := dec.Decode(&event); err != nil {
+			return fmt.Errorf("array element %d: %s", n, err.Error())
+		}
+		if event == nil {
+			continue
+		}
+		if err := emit(event); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("unterminated array: %s", err.Error())
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the array of events")
+	}
+	return nil
+}
+
+// jsonStreamEvents emits each of a sequence of JSON objects, such as indented
+// events written one after another.
+func jsonStreamEvents(r io.Reader, emit func(map[string]interface{}) error) error {
+	dec := json.NewDecoder(r)
+	for n := 1; ; n++ {
+		var event map[string]interface{}
+		if err := dec.Decode(&event); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("object %d: %s", n, err.Error())
+		}
+		if event == nil {
+			continue
+		}
+		if err := emit(event); err != nil {
+			return err
+		}
+	}
+}
+
+// csvEvents converts the rows of bbot's CSV output into events. The event data
+// column becomes the host of DNS_NAME events, the IP address column the
+// resolved hosts and the event tags column the tags.
+func csvEvents(r io.Reader, emit func(map[string]interface{}) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(row []string, names ...string) string {
+		for _, name := range names {
+			if i, found := columns[name]; found && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+		}
+		return ""
+	}
+	split := func(s string) []interface{} {
+		values := []interface{}{}
+		for _, v := range strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' }) {
+			values = append(values, v)
+		}
+		return values
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		event := map[string]interface{}{
+			"type":   field(row, "event type"),
+			"data":   field(row, "event data"),
+			"module": field(row, "source module"),
+		}
+		if event["type"] == "DNS_NAME" {
+			event["host"] = event["data"]
+		}
+		event["resolved_hosts"] = split(field(row, "ip address", "ip"))
+		event["tags"] = split(field(row, "event tags", "tags"))
+		if distance, err := strconv.Atoi(field(row, "scope distance")); err == nil {
+			event["scope_distance"] = float64(distance)
+		}
+		if err := emit(event); err != nil {
+			return err
+		}
+	}
+}
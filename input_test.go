@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readEvents returns the types of the NDJSON events read from in.
+func readEvents(t *testing.T, in io.Reader) []string {
+	t.Helper()
+	types := []string{}
+	scanner := newEventScanner(in)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid event %q: %s", scanner.Text(), err.Error())
+		}
+		eventType, _ := entry["type"].(string)
+		types = append(types, eventType)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return types
+}
+
+func gzipped(s string) string {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	zw.Write([]byte(s))
+	zw.Close()
+	return b.String()
+}
+
+func TestDetectInput(t *testing.T) {
+	ndjson := `{"type":"SCAN"}` + "\n" + `{"type":"DNS_NAME"}` + "\n"
+	csvOutput := "Event type,Event data,IP Address,Source Module,Scope Distance,Event Tags\nDNS_NAME,www.example.com,192.0.2.1,speculate,0,in-scope\n"
+	tests := []struct {
+		name       string
+		in         string
+		format     string
+		wantFormat string
+		wantTypes  []string
+		wantErr    bool
+	}{
+		{"ndjson", ndjson, "", "bbot NDJSON", []string{"SCAN", "DNS_NAME"}, false},
+		{"byte order mark", "\xef\xbb\xbf" + ndjson, "", "bbot NDJSON", []string{"SCAN", "DNS_NAME"}, false},
+		{"indented json", "{\n  \"type\": \"SCAN\"\n}\n{\n  \"type\": \"DNS_NAME\"\n}\n", "", "indented bbot JSON", []string{"SCAN", "DNS_NAME"}, false},
+		{"json array", `[{"type":"SCAN"}, {"type":"DNS_NAME"}]`, "", "bbot JSON array", []string{"SCAN", "DNS_NAME"}, false},
+		{"csv", csvOutput, "", "bbot CSV", []string{"DNS_NAME"}, false},
+		{"gzip", gzipped(ndjson), "", "gzip compressed bbot NDJSON", []string{"SCAN", "DNS_NAME"}, false},
+		{"empty", "", "", "bbot NDJSON", []string{}, false},
+		{"format ndjson", ndjson, "ndjson", "bbot NDJSON", []string{"SCAN", "DNS_NAME"}, false},
+		{"format csv", csvOutput, "csv", "bbot CSV", []string{"DNS_NAME"}, false},
+		{"format sqlite", ndjson, "sqlite", "", nil, true},
+		{"zip", "PK\x03\x04rest", "", "", nil, true},
+		{"unrecognized", "hello world\n", "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := detectInput(strings.NewReader(tt.in), tt.format, eventFilter{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectInput() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer in.Close()
+			if in.format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", in.format, tt.wantFormat)
+			}
+			if got := readEvents(t, in); !reflect.DeepEqual(got, tt.wantTypes) {
+				t.Errorf("events = %v, want %v", got, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestCSVEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "dns name",
+			in:   "Event type,Event data,IP Address,Source Module,Scope Distance,Event Tags\nDNS_NAME,www.example.com,\"192.0.2.1,192.0.2.2\",speculate,1,in-scope a-record\n",
+			want: []map[string]interface{}{{
+				"type": "DNS_NAME", "data": "www.example.com", "host": "www.example.com", "module": "speculate",
+				"resolved_hosts": []interface{}{"192.0.2.1", "192.0.2.2"}, "tags": []interface{}{"in-scope", "a-record"}, "scope_distance": float64(1),
+			}},
+		},
+		{
+			name: "other event without distance",
+			in:   "event type,event data,ip,tags\nOPEN_TCP_PORT,www.example.com:443,192.0.2.1,\n",
+			want: []map[string]interface{}{{
+				"type": "OPEN_TCP_PORT", "data": "www.example.com:443", "module": "",
+				"resolved_hosts": []interface{}{"192.0.2.1"}, "tags": []interface{}{},
+			}},
+		},
+		{
+			name: "short row",
+			in:   "Event type,Event data,IP Address\nDNS_NAME,www.example.com\n",
+			want: []map[string]interface{}{{
+				"type": "DNS_NAME", "data": "www.example.com", "host": "www.example.com", "module": "",
+				"resolved_hosts": []interface{}{}, "tags": []interface{}{},
+			}},
+		},
+		{"header only", "Event type,Event data\n", []map[string]interface{}{}, false},
+		{"empty", "", nil, true},
+		{"bad quoting", "Event type,Event data\nDNS_NAME,\"www\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []map[string]interface{}{}
+			err := csvEvents(strings.NewReader(tt.in), func(event map[string]interface{}) error {
+				got = append(got, event)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("csvEvents() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("csvEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONArrayEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"events", `[{"type":"SCAN"},{"type":"DNS_NAME"}]`, []string{"SCAN", "DNS_NAME"}, false},
+		{"null elements skipped", `[null, {"type":"SCAN"}]`, []string{"SCAN"}, false},
+		{"empty array", `[]`, []string{}, false},
+		{"trailing whitespace", "[{\"type\":\"SCAN\"}]\n\n", []string{"SCAN"}, false},
+		{"invalid element", `[{"type":"SCAN"}, 1]`, nil, true},
+		{"unterminated", `[{"type":"SCAN"}`, nil, true},
+		{"data after the array", `[{"type":"SCAN"}] {"type":"DNS_NAME"}`, nil, true},
+		{"empty input", ``, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			err := jsonArrayEvents(strings.NewReader(tt.in), func(event map[string]interface{}) error {
+				eventType, _ := event["type"].(string)
+				got = append(got, eventType)
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("jsonArrayEvents() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("jsonArrayEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
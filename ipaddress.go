@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// handleIPAddress creates a host for the IP of an IP_ADDRESS event with
+// -force-hosts, so that IPs in scope without a DNS name are imported. Events
+// outside of bbot's scope, with a scope_distance above 0, are skipped, as are
+// IPv6 addresses in a project without IPv6 hosts unless -ipv6-policy is create.
+func (im *importer) handleIPAddress(entry map[string]interface{}) {
+	stats := im.metrics.handler("IP_ADDRESS")
+	data, _ := entry["data"].(string)
+	parsed := net.ParseIP(data)
+	if parsed == nil {
+		stats.Skipped++
+		return
+	}
+	ip := parsed.String()
+	if outsideScope(entry) {
+		stats.Skipped++
+		return
+	}
+	if im.updateHost(ip, func(host *lair.Host) bool { return false }) {
+		stats.Matched++
+		return
+	}
+	if !im.opts.forceHosts || isIPv6(ip) && !im.projectIPv6 && im.opts.ipv6Policy != "create" {
+		stats.Skipped++
+		return
+	}
+	im.project.Hosts = append(im.project.Hosts, lair.Host{
+		IPv4:           ip,
+		Hostnames:      []string{},
+		Tags:           im.opts.hostTags,
+		LastModifiedBy: lastModifiedBy,
+	})
+	stats.Matched++
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// ipv6Policies are the values accepted by -ipv6-policy.
+var ipv6Policies = []string{"skip", "create", "note"}
+
+const ipv6NotePrefix = "drone-bbot: IPv6 addresses of "
+
+// isIPv6 reports whether s is an IPv6 address rather than an IPv4 one.
+func isIPv6(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() == nil
+}
+
+// hasIPv6Hosts reports whether any of hosts has an IPv6 address.
+func hasIPv6Hosts(hosts []lair.Host) bool {
+	for _, host := range hosts {
+		if isIPv6(host.IPv4) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyIPv6 reports whether every IP in resolved is IPv6.
+func onlyIPv6(resolved []string) bool {
+	for _, ip := range resolved {
+		if !isIPv6(ip) {
+			return false
+		}
+	}
+	return len(resolved) > 0
+}
+
+// handleIPv6Only applies -ipv6-policy to a DNS name that resolved only to
+// IPv6 addresses in a project without IPv6 hosts.
+func (im *importer) handleIPv6Only(name string, resolved []string) {
+	switch im.opts.ipv6Policy {
+	case "create":
+		for _, ip := range resolved {
+			if !im.updateHost(ip, func(host *lair.Host) bool {
+				return mergeHost(host, []string{name}, im.opts.hostTags)
+			}) {
+				im.project.Hosts = append(im.project.Hosts, lair.Host{
+					IPv4:           ip,
+					Hostnames:      []string{name},
+					Tags:           im.opts.hostTags,
+					LastModifiedBy: lastModifiedBy,
+				})
+			}
+		}
+	case "note":
+		im.ipv6Only[name], _ = appendUnique(im.ipv6Only[name], resolved...)
+	default:
+		im.ipv6Skipped, _ = appendUnique(im.ipv6Skipped, name)
+	}
+}
+
+// applyIPv6Notes adds a note listing the IPv6 addresses of each IPv6-only DNS
+// name to the IPv4 hosts Lair already has the name on. Names without such a
+// host are skipped and reported.
+func (im *importer) applyIPv6Notes() {
+	names := []string{}
+	for name := range im.ipv6Only {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addrs := im.ipv6Only[name]
+		sortIPs(addrs)
+		related := false
+		for _, ip := range im.names[strings.ToLower(name)] {
+			if isIPv6(ip) {
+				continue
+			}
+			related = im.updateHost(ip, func(host *lair.Host) bool {
+				title := ipv6NotePrefix + name
+				if hasNote(host.Notes, title) {
+					return false
+				}
+				host.Notes = append(host.Notes, lair.Note{
+					Title:          title,
+					Content:        sanitizeText(fmt.Sprintf("bbot resolved %s only to IPv6:\n\n%s\n", name, strings.Join(addrs, "\n"))),
+					LastModifiedBy: lastModifiedBy,
+				})
+				return true
+			}) || related
+		}
+		if !related {
+			im.ipv6Skipped, _ = appendUnique(im.ipv6Skipped, name)
+		}
+	}
+}
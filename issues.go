@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// newIssue returns an issue identified by pluginID. Lair merges imported
+// issues with existing ones that share a plugin ID, so the same pluginID must
+// be used for every occurrence of a finding.
+func newIssue(pluginID, title string, cvss float64, description, solution string) lair.Issue {
+	return lair.Issue{
+		Title:          title,
+		CVSS:           cvss,
+		Rating:         cvssRating(cvss),
+		Description:    description,
+		Solution:       solution,
+		Status:         lair.StatusGrey,
+		PluginIDs:      []lair.PluginID{{Tool: tool, ID: pluginID}},
+		IdentifiedBy:   []lair.IdentifiedBy{{Tool: tool}},
+		LastModifiedBy: lastModifiedBy,
+	}
+}
+
+// cvssRating returns the rating Lair assigns to an issue with the given CVSS
+// score.
+func cvssRating(cvss float64) string {
+	switch {
+	case cvss >= 7:
+		return "high"
+	case cvss >= 4:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// addIssue records issue, merging its hosts, evidence, CVEs, identifying tools
+// and notes into an issue already recorded during this import with the same
+// plugin ID.
+func (im *importer) addIssue(issue lair.Issue) {
+	key := issue.PluginIDs[0].ID
+	idx, found := im.issueIndex[key]
+	if !found {
+		issue.Evidence = sanitizeText(issue.Evidence)
+		im.issueIndex[key] = len(im.project.Issues)
+		im.project.Issues = append(im.project.Issues, issue)
+		im.metrics.IssuesCreated++
+		return
+	}
+	im.metrics.IssuesDeduped++
+	existing := &im.project.Issues[idx]
+	for _, host := range issue.Hosts {
+		known := false
+		for _, h := range existing.Hosts {
+			if h == host {
+				known = true
+				break
+			}
+		}
+		if !known {
+			existing.Hosts = append(existing.Hosts, host)
+		}
+	}
+	if issue.Evidence != "" && !strings.Contains(existing.Evidence, issue.Evidence) {
+		existing.Evidence += "\n" + sanitizeText(issue.Evidence)
+	}
+	existing.CVEs, _ = appendUnique(existing.CVEs, issue.CVEs...)
+	for _, by := range issue.IdentifiedBy {
+		known := false
+		for _, b := range existing.IdentifiedBy {
+			known = known || b == by
+		}
+		if !known {
+			existing.IdentifiedBy = append(existing.IdentifiedBy, by)
+		}
+	}
+	for _, note := range issue.Notes {
+		if !hasNote(existing.Notes, note.Title) {
+			existing.Notes = append(existing.Notes, note)
+		}
+	}
+}
+
+// eventIPs returns the IPs an event refers to, taken from resolved_hosts and
+// from data.host when it is an IP address.
+func eventIPs(entry map[string]interface{}) []string {
+	ips := resolvedHosts(entry)
+	if data, ok := entry["data"].(map[string]interface{}); ok {
+		if host, _ := data["host"].(string); net.ParseIP(host) != nil {
+			ips, _ = appendUnique(ips, host)
+		}
+	}
+	if host, _ := entry["host"].(string); net.ParseIP(host) != nil {
+		ips, _ = appendUnique(ips, host)
+	}
+	return ips
+}
+
+// urlPort returns the port of rawURL, using the scheme default when the URL
+// does not specify one.
+func urlPort(rawURL string) int {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	if port, err := strconv.Atoi(u.Port()); err == nil {
+		return port
+	}
+	if u.Scheme == "https" {
+		return 443
+	}
+	return 80
+}
+
+// issueHosts returns the issue hosts for every IP an event refers to on port.
+func issueHosts(entry map[string]interface{}, port int) []lair.IssueHost {
+	hosts := []lair.IssueHost{}
+	for _, ip := range eventIPs(entry) {
+		hosts = append(hosts, lair.IssueHost{IPv4: ip, Port: port, Protocol: "tcp"})
+	}
+	return hosts
+}
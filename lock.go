@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockGracePeriod is how long a lock file without a PID is assumed to belong to
+// a run that is still starting. Locks are created with their PID already
+// written, so a file without one was left by something else or cut short.
+const lockGracePeriod = time.Minute
+
+// acquireLock creates the lock file at path containing the current PID. The
+// file is written under a temporary name and linked into place, so that another
+// run never sees a lock without its PID. An existing lock is considered stale
+// and replaced when the process that wrote it is no longer running, or when it
+// is older than maxAge (if maxAge is non-zero). The returned function removes
+// the lock if it is still the one this run created.
+func acquireLock(path string, maxAge time.Duration) (func(), error) {
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	data := fmt.Sprintf("%d\n%s\n", os.Getpid(), time.Now().UTC().Format(time.RFC3339))
+	if err := os.WriteFile(tmp, []byte(data), 0644); err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	own, err := os.Stat(tmp)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp, path)
+		if err == nil {
+			return func() {
+				if info, err := os.Stat(path); err == nil && os.SameFile(info, own) {
+					os.Remove(path)
+				}
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		stale, reason, info, err := lockIsStale(path, maxAge)
+		if err != nil {
+			return nil, err
+		}
+		if !stale {
+			return nil, fmt.Errorf("%s is held by %s", path, reason)
+		}
+		if err := removeStaleLock(path, info); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("unable to acquire %s", path)
+}
+
+// lockIsStale reports whether the lock at path can be safely removed, along with
+// a description of the lock holder and the file that was checked. A lock whose
+// PID can not be read is only stale once it is older than lockGracePeriod.
+func lockIsStale(path string, maxAge time.Duration) (bool, string, os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return true, "", nil, nil
+	}
+	if err != nil {
+		return false, "", nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, "", nil, err
+	}
+	age := time.Since(info.ModTime())
+	pid, err := strconv.Atoi(strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)[0])
+	if err != nil {
+		holder := fmt.Sprintf("an unknown process since %s", info.ModTime().Format(time.RFC3339))
+		return age > lockGracePeriod, holder, info, nil
+	}
+	holder := fmt.Sprintf("pid %d since %s", pid, info.ModTime().Format(time.RFC3339))
+	if !processAlive(pid) {
+		return true, holder, info, nil
+	}
+	if maxAge > 0 && age > maxAge {
+		return true, holder, info, nil
+	}
+	return false, holder, info, nil
+}
+
+// removeStaleLock removes the lock at path that lockIsStale judged stale as
+// info. The lock is first moved aside, and put back when it turns out to be a
+// different file, a lock another run created after the stale one was removed.
+func removeStaleLock(path string, info os.FileInfo) error {
+	if info == nil {
+		return nil
+	}
+	aside := fmt.Sprintf("%s.%d.stale", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+	moved, err := os.Stat(aside)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(moved, info) {
+		if err := os.Link(aside, path); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireLock(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour)
+	tests := []struct {
+		name    string
+		content string
+		modTime time.Time
+		maxAge  time.Duration
+		wantErr bool
+	}{
+		{"no lock", "", time.Time{}, 0, false},
+		{"held by a running process", fmt.Sprintf("%d\n", os.Getpid()), time.Now(), 0, true},
+		{"running process within max age", fmt.Sprintf("%d\n", os.Getpid()), old, 3 * time.Hour, true},
+		{"running process beyond max age", fmt.Sprintf("%d\n", os.Getpid()), old, time.Hour, false},
+		{"no pid within grace period", "", time.Now(), 0, true},
+		{"no pid beyond grace period", "garbage", old, 0, false},
+		{"exited process", "999999999\n", time.Now(), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "exited process" && runtime.GOOS == "windows" {
+				t.Skip("processAlive can not tell whether a process exited on Windows")
+			}
+			path := filepath.Join(t.TempDir(), "drone-bbot.lock")
+			if !tt.modTime.IsZero() {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(path, tt.modTime, tt.modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			release, err := acquireLock(path, tt.maxAge)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("acquireLock() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if data, _ := os.ReadFile(path); string(data) != tt.content {
+					t.Errorf("lock changed to %q by a failed acquireLock()", data)
+				}
+				return
+			}
+			data, _ := os.ReadFile(path)
+			if !strings.HasPrefix(string(data), fmt.Sprintf("%d\n", os.Getpid())) {
+				t.Errorf("lock holds %q, want the current pid", data)
+			}
+			if _, err := acquireLock(path, tt.maxAge); err == nil {
+				t.Errorf("acquireLock() of a held lock succeeded")
+			}
+			release()
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("lock left behind after release: %v", err)
+			}
+		})
+	}
+}
+
+func TestReleaseKeepsReplacedLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drone-bbot.lock")
+	release, err := acquireLock(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := path + ".other"
+	if err := os.WriteFile(other, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(other, path); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("release removed a lock created by another run: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// lockedTags mark hosts curated by hand in Lair that drone-bbot must not
+// change.
+var lockedTags = []string{"locked", "manual"}
+
+// isLockedHost reports whether host has one of the lockedTags.
+func isLockedHost(host lair.Host) bool {
+	for _, tag := range host.Tags {
+		for _, locked := range lockedTags {
+			if strings.EqualFold(strings.TrimSpace(tag), locked) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostChanges describes the changes between before and after, the same host
+// as exported from Lair and as it would be imported.
+func hostChanges(before, after lair.Host) []string {
+	changes := []string{}
+	if added := missing(after.Hostnames, before.Hostnames); len(added) > 0 {
+		changes = append(changes, "add hostnames "+strings.Join(added, ", "))
+	}
+	if added := missing(after.Tags, before.Tags); len(added) > 0 {
+		changes = append(changes, "add tags "+strings.Join(added, ", "))
+	}
+	if after.IsFlagged && !before.IsFlagged {
+		changes = append(changes, "flag the host")
+	}
+	if added := missing(noteTitles(after.Notes), noteTitles(before.Notes)); len(added) > 0 {
+		changes = append(changes, "add notes "+strings.Join(added, ", "))
+	}
+	services := map[string]lair.Service{}
+	for _, service := range before.Services {
+		services[fmt.Sprintf("%d/%s", service.Port, service.Protocol)] = service
+	}
+	for _, service := range after.Services {
+		key := fmt.Sprintf("%d/%s", service.Port, service.Protocol)
+		existing, found := services[key]
+		switch {
+		case !found:
+			changes = append(changes, "add service "+key)
+		case len(missing(noteTitles(service.Notes), noteTitles(existing.Notes))) > 0 || service.Product != existing.Product:
+			changes = append(changes, "update service "+key)
+		}
+	}
+	if len(after.WebDirectories) > len(before.WebDirectories) {
+		changes = append(changes, fmt.Sprintf("add %d web directories", len(after.WebDirectories)-len(before.WebDirectories)))
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "update the host")
+	}
+	return changes
+}
+
+// missing returns the values of values that are not in known.
+func missing(values, known []string) []string {
+	seen := make(map[string]bool)
+	for _, value := range known {
+		seen[value] = true
+	}
+	result := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// noteTitles returns the titles of notes.
+func noteTitles(notes []lair.Note) []string {
+	titles := []string{}
+	for _, note := range notes {
+		titles = append(titles, note.Title)
+	}
+	return titles
+}
+
+// applyLockedHosts leaves existing hosts with a lockedTag out of the import,
+// and returns the changes that would have been made to them keyed by IP.
+func (im *importer) applyLockedHosts(existing []lair.Host) map[string][]string {
+	locked := make(map[string][]string)
+	for _, host := range existing {
+		if !isLockedHost(host) {
+			continue
+		}
+		if im.updated[host.IPv4] {
+			locked[host.IPv4] = hostChanges(host, im.existingIPs[host.IPv4])
+			delete(im.updated, host.IPv4)
+		}
+		delete(im.existingIPs, host.IPv4)
+	}
+	return locked
+}
+
+// lockedTable formats the changes withheld from locked hosts as a table with
+// one row per host, sorted by IP.
+func lockedTable(locked map[string][]string) []string {
+	ips := []string{}
+	for ip := range locked {
+		ips = append(ips, ip)
+	}
+	sortIPs(ips)
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IP\tWITHHELD CHANGES")
+	for _, ip := range ips {
+		fmt.Fprintf(w, "%s\t%s\n", ip, strings.Join(locked[ip], "; "))
+	}
+	w.Flush()
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
@@ -1,174 +1,725 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"bytes"
 	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lair-framework/api-server/client"
-	"github.com/lair-framework/go-lair"
 )
 
 const (
 	version = "1.0.0"
 	tool    = "drone-bbot"
 	usage   = `
-Parses a bbot JSON file into a Lair project, extracting DNS name and IP.
+Parses a bbot JSON file into a Lair project, extracting DNS names, IPs and open
+TCP ports. Ports that bbot's speculate module only guessed are not imported
+unless -speculated-ports is set.
 
 Usage:
-  drone-bbot [options] <id> <filename>
-  export LAIR_ID=<id>; drone-bbot [options] <filename>
+  drone-bbot [options] <id> [<filename>...]
+  export LAIR_ID=<id>; drone-bbot [options] [<filename>...]
+  drone-bbot -check-auth <id>
+  drone-bbot [options] serve -projects <id>,... [-listen <addr>] [-port <port>]
+  drone-bbot [options] merge <src-id> <dst-id> [-filter <field>=<value>]...
+  drone-bbot [options] backfill <id> <filename>
+  drone-bbot [options] prune-tags <id>
+  drone-bbot [options] compare <old-file> <new-file> [-import-delta <id>]
+<filename> may be bbot NDJSON, indented JSON events, a JSON array of events
+(output.json), bbot CSV, a bbot SQLite database (output.sqlite), a gzip file of
+one of these, or a bbot scan directory, the format is detected automatically.
+In a scan directory such as ~/.bbot/scans/<name>, the event output is found
+automatically, falling back to asset-inventory.csv, and the scan name, targets,
+modules and start time from preset.yml and the SCAN event are recorded in the
+Lair command of the import.
+Several files and glob patterns such as scans/*.ndjson are merged into a single
+import, with the hosts and hostnames found in more than one file merged.
+When <filename> is - or missing, events are read from stdin until it is closed,
+such as with bbot ... --json | drone-bbot <id> -.
+When <filename> is a named pipe (FIFO), each writer's output is imported when it
+closes the pipe, and the pipe is reopened for the next writer until interrupted.
+With serve and named pipes, hosts that do not exist in lair are reported once
+per project, and again only after hosts are added to the project.
+Each import of serve or a named pipe logs its events by type, how long reading
+and importing took, and how long after bbot's newest event it finished.
+Hosts tagged locked or manual in Lair are never changed, the changes drone-bbot
+would have made to them are listed in the summary instead. Hosts tagged deleted,
+removed or hidden are not changed either, and are listed separately when bbot
+finds them again.
+Registrant and abuse contacts in WHOIS and RDAP events are added as people in
+the registrar-contact group, unless a person with the same name, email and phone
+already exists.
+Output is colorized when it is written to a terminal, set NO_COLOR to disable
+colors.
+Commands:
+  serve           run an HTTP server accepting bbot NDJSON bodies on
+                  POST /import?project=<id>, responding with the import summary
+                  as JSON. Requests must send "Authorization: Bearer <token>" with
+                  the token in DRONE_BBOT_SERVE_TOKEN, and may only import into
+                  the comma separated project ids of -projects. -listen sets the
+                  listening address (default: 127.0.0.1), -port the listening port
+                  (default: 8089) and -max-body-mb the largest accepted body
+                  (default: 256)
+  merge           copy hosts from one project into another, merging hostnames, tags,
+                  notes, services and web directories into hosts that already exist
+                  unless they are locked or removed, or -additive-only is set. The
+                  issues of the copied hosts follow them, and the project notes,
+                  netblocks, auth interfaces and people the project lacks are copied.
+                  -filter selects hosts by domain=<domain>, ip=<ip, CIDR or pattern>
+                  or tag=<tag> and may be repeated, all filters must match
+  backfill        attach the DNS names of an earlier import to the hosts created in
+                  the project since, such as by drone-nmap, without importing anything
+                  else. <filename> is the imported bbot output, or the hosts that did
+                  not exist in lair as logged, as a -not-found-note or as the notFound
+                  of a JSON summary
+  prune-tags      list the ephemeral tags whose -tag-ttl has passed, with the hosts
+                  that still carry them
+  compare         compare two bbot scans without contacting Lair, listing added (+)
+                  and removed (-) hostnames, hostnames that resolve to different IPs
+                  (~) and new open ports (>). -import-delta imports only those
+                  DNS_NAME and OPEN_TCP_PORT events of the new scan into a project
 Options:
   -v              show version and exit
   -h              show usage and exit
   -k              allow insecure SSL connections
+  -check-auth     verify that the credentials in LAIR_API_SERVER can export and
+                  import the project, without changing it, and exit
+  -format         read <filename> as ndjson, json (an array or indented events), csv
+                  (bbot's output.csv) or sqlite (the event table of bbot's
+                  output.sqlite) instead of detecting its format
   -force-hosts    import all hosts into Lair, default behaviour is to only import
                   DNS records for hosts that already exist in the project
-  -tags           a comma separated list of tags to add to every host that is imported
+  -host-display   how created hosts are shown in Lair: ip, or hostname to list their
+                  primary hostname first, so they do not show up as bare IPs.
+                  In-scope, shorter names are preferred. Only hosts this import
+                  creates are affected, Lair appends the hostnames of existing
+                  hosts (default: ip)
+  -scope          a comma separated list of domains in scope. With -force-hosts, hosts
+                  are only created for hostnames registered under these domains or
+                  bbot's targets, even if bbot considered others in scope. Without
+                  -scope or bbot's SCAN event every domain is imported with a warning
+  -include-unresolved
+                  record the names of DNS_NAME_UNRESOLVED events that are not hostnames
+                  in the project, in a project note (note) or as hostnames of a grey
+                  placeholder host 0.0.0.0 tagged unresolved (host)
+  -allow-foreign-domains
+                  create hosts for hostnames outside of -scope and bbot's targets
+                  with -force-hosts
+  -skip-cloud-ips
+                  with -force-hosts, do not create hosts for IPs that bbot tagged as
+                  belonging to a cloud provider, which are often reassigned. Hosts
+                  are always tagged cloud:<provider> from bbot's cloud-<provider>
+                  tags, and cloud:azure-tenant for domains of AZURE_TENANT events
+  -additive-only  only create new hosts, issues, auth interfaces and notes, never
+                  change hosts or issues that already exist in the project, such as
+                  by adding hostnames or tags
+  -only           a comma separated list of the event types to import, such as DNS_NAME
+                  to only refresh hostnames, other events are skipped without being
+                  decoded (default: all)
+  -modules        a comma separated list of bbot modules, such as httpx,nuclei, whose
+                  events are imported, events of other modules are skipped. For a
+                  SQLite database, -only and -modules select the events in SQL
+  -tags           a comma separated list of tags to add to every host that is imported,
+                  whitespace around tags and empty entries are ignored
+  -tag            a tag to add to every host that is imported, may be repeated
+  -tags-file      file of tags to add to every host that is imported, one or more
+                  comma separated tags per line, lines starting with # are ignored
+  -tag-ttl        how long tags starting with ephemeral: stay valid, such as 72h. The
+                  expiry is recorded in a host note and prune-tags lists expired tags
+  -tag-namespace  prefix added to every tag written by drone-bbot, use "" to disable
+                  (default: bbot:)
+  -slug-tags      lowercase the tags written by drone-bbot and replace spaces and
+                  punctuation other than ":._-" with dashes. Tags are always stripped
+                  of control characters and cut to 128 characters
+  -migrate-tags   add the namespaced form of legacy unprefixed drone tags to existing
+                  hosts and list the legacy tags, which must be removed in Lair
+  -probe-unmatched
+                  TCP connect scan hosts that do not exist in the project and import
+                  those that respond, along with their open ports. Only IPs resolved
+                  by DNS names within bbot's scope (scope_distance 0), or by names
+                  under -scope or bbot's targets, are probed
+  -probe-ports    a comma separated list of ports to probe (default: %s)
+  -probe-rate     maximum number of probe connections to start per second, at most
+                  10000 (default: 100)
+  -probe-timeout  connection timeout for each probe (default: 2s)
+  -speculated-ports
+                  import the ports bbot's speculate module guessed as services of the
+                  hosts in the project, with a drone-bbot: unconfirmed note. Services
+                  that already exist, such as those found by nmap, are not changed
+  -enrich         look up the hosts that had DNS names but do not exist in lair in
+                  shodan or censys, and create those with exposed services reported
+                  by it. Censys credentials are read from CENSYS_API_ID and
+                  CENSYS_API_SECRET
+  -shodan-key     Shodan API key for -enrich shodan (default: SHODAN_API_KEY)
+  -passive-dns    look up the hostnames added to hosts in virustotal or securitytrails
+                  and note when each was first seen resolving to its host, reporting
+                  recently changed CNAME records that may allow a takeover
+  -passive-dns-key
+                  API key for -passive-dns (default: VT_API_KEY or
+                  SECURITYTRAILS_API_KEY)
+  -low-confidence create hosts that do not exist in the project when at least this many
+                  distinct DNS names resolve to them, or when bbot or -probe-unmatched
+                  found an open port on them, with status grey and tagged
+                  <namespace>low-confidence, others are still skipped
+  -ipv6-policy    how to handle DNS names that only resolve to IPv6 when the project has
+                  no IPv6 hosts: skip and list them, create IPv6 hosts (the Lair API
+                  server ignores hosts it does not accept as IPv4), or note the IPv6
+                  addresses on the IPv4 hosts that already have the name (default: skip)
+  -max-hosts-per-domain
+                  maximum number of new hosts to create for each registrable domain,
+                  hosts over the limit are summarized in a project note (default: no
+                  limit)
+  -flag-when      tag hosts <namespace>flagged when they match an expression such as
+                  'port=3389 or hostname=*.dev.example.com and tag!=external', and
+                  flag the hosts the import creates. Lair does not keep the flag of
+                  existing hosts. Fields are ip, hostname, tag, service-tag, port
+                  and finding.severity, the most severe issue of the host such as
+                  finding.severity>=high. Conditions are combined with and/or, !=
+                  matches when no value is equal, and port and finding.severity
+                  accept >, >=, < and <=
+  -import-auth-interfaces
+                  create Lair auth interfaces for URL and TECHNOLOGY events that
+                  indicate login portals such as OWA, VPN, SSO and admin panels
+  -export-burp    write a Burp Suite project configuration scoping the web endpoints
+                  seen in URL and HTTP_RESPONSE events to this file, and a plain list
+                  of the URLs to the same path with a .txt extension
+  -header-issues  create informational issues for web services whose responses lack
+                  the Strict-Transport-Security, Content-Security-Policy or
+                  X-Frame-Options headers
+  -findings       import FINDING events such as exposed panels and interesting files
+                  as low severity issues (issue) or as notes on their hosts (note),
+                  with the bbot description and module (default: only directory
+                  listings and subdomain takeover candidates are imported)
+  -qa-sample      after importing, re-resolve a random sample of this many imported
+                  hostnames and report how many no longer resolve to their host
+  -cert-issues    report self-signed TLS certificates, and certificates that have
+                  expired or expire within -cert-expiry-days, as informational issues.
+                  Certificates are read from the tls details of HTTP_RESPONSE events
+                  and always recorded as a note on their service
+  -cert-expiry-days
+                  days before expiry at which -cert-issues reports a certificate
+                  (default: 30)
+  -not-found-note
+                  record the hosts that had DNS names but do not exist in lair in a
+                  dated project note grouped by domain, instead of logging each one
+  -vhost-notes    add a note to each host explaining the virtual host hostnames found
+                  by bbot's vhost module, which are always added to the host
+  -email-notes    record the addresses of EMAIL_ADDRESS events, such as those found by
+                  the emailformat and hunterio modules, in a project note per domain
+  -osint-notes    record the code repositories and social media profiles of
+                  CODE_REPOSITORY and SOCIAL events, with their platform and module,
+                  in a project note
+  -provenance     add the bbot discovery chain of VULNERABILITY and -findings issues to
+                  their evidence, such as DNS_NAME -> URL -> VULNERABILITY with the
+                  module of each step; with -only, events of other types are
+                  missing from the chain
+  -max-evidence-bytes
+                  cut the raw event notes of VULNERABILITY issues, HTTP banner and
+                  TLS certificate notes and the evidence of issues to this many
+                  bytes, keeping Lair documents small (default: no limit)
+  -evidence-dir   write the full text of evidence cut by -max-evidence-bytes to a
+                  file in this directory named by its SHA-256, which the cut text
+                  points to
+  -txt-secrets    create informational issues for DNS TXT records that expose API keys,
+                  credentials, internal hostnames or SaaS verification tokens
+  -txt-rules      file of additional TXT record rules, one "<name>: <regex>" per line,
+                  implies -txt-secrets
+  -track-seen     record the first and last date each hostname was seen on a host in a
+                  "hostnames seen" note, added only by imports that change the dates,
+                  the latest note holds the current dates. Runs that do not read every
+                  DNS_NAME event, such as with -only, -modules or -max-duration, are
+                  not recorded
+  -retire-missing
+                  tag hostnames <namespace>retired:<hostname> and add a dated note
+                  once they have been missing from this many consecutive imports,
+                  implies -track-seen
+  -email-to       a comma separated list of addresses to email the import summary to,
+                  SMTP_USERNAME and SMTP_PASSWORD are used to authenticate when set
+  -email-from     sender address of the summary email (default: drone-bbot@localhost)
+  -smtp           SMTP server used to send the summary email (default: localhost:25)
+  -ticket-system  open a ticket in jira or servicenow for each imported high or critical
+                  issue that was not already in the project, TICKET_USERNAME and
+                  TICKET_PASSWORD are used to authenticate
+  -ticket-url     base URL of the JIRA or ServiceNow instance
+  -ticket-queue   JIRA project key or ServiceNow assignment group for new tickets
+  -ticket-template
+                  path to a Go text/template for the ticket body, executed with
+                  .Project, .Issue (a lair.Issue) and .Severity (high or critical)
+  -min-severity   only import issues of at least this severity: info, low, medium, high
+                  or critical (default: info)
+  -skipped-issues-file
+                  write the issues skipped by -min-severity to this file as JSON
+  -command-label  label of the Lair command recorded for the import, such as the
+                  engagement phase. Lair requires and appends a command on every
+                  import, runs with the same label are listed identically
+  -operator       name of the person running the import, recorded as
+                  drone-bbot(<operator>) in the last modified by field of everything
+                  the import creates or changes
+  -service-tags   tag the web services of URL, HTTP_RESPONSE and TECHNOLOGY events with
+                  http, tls and bbot-module:<module>, recorded as one
+                  "drone-bbot: service tag <tag>" note per tag as Lair services
+                  have no tags
+  -cpe-notes      add a "drone-bbot: CPE <cpe>" note to the web service or host for each
+                  technology bbot identifies, for correlation against CVE feeds
+  -screenshots    reference the gowitness screenshots of WEBSCREENSHOT events in a note
+                  on their web service (note), or also upload the images to the
+                  service's files in Lair (upload). With -scan-dir, screenshot paths
+                  are looked up in the scan directory
+  -scan-dir       bbot scan directory, each host gets a note listing the screenshots and
+                  stored HTTP responses in it that name one of its hostnames or its IP
+  -tui            review the created and updated hosts and the findings before
+                  importing them at a line command prompt (a REPL, not a full screen
+                  interface) that lists, searches, excludes and includes them by
+                  number, "?" lists the commands
+  -airgap         guarantee no network connections are made to anything other than
+                  the Lair API server, fails if an enabled option would require one
+  -detect-changes
+                  report whether the import would change the project without importing,
+                  exits with status 0 when there are no changes and 2 when there are
+  -max-project-hosts
+                  refuse an import that would take the project above this many hosts
+                  (default: no limit)
+  -force          import even if it would more than double the number of hosts in the
+                  project or exceed -max-project-hosts
+  -max-duration   stop reading events after this duration, import what was read and exit
+                  with status 3, writing a checkpoint so the next run continues from
+                  where this one stopped (default: no limit)
+  -checkpoint     path of the checkpoint file used with -max-duration, a completed
+                  import removes it (default: <filename>.checkpoint)
+  -max-payload-mb split imports whose JSON payload is larger than this many MiB into
+                  several requests, use 0 to send everything at once (default: 8)
+  -batch-state    path of a file recording which parts of a split import Lair has
+                  accepted. When a part fails, re-running with the same input and
+                  -batch-state sends only the parts that were not accepted. Only a
+                  digest of the input and of each part is recorded, not the events.
+                  A completed import removes it. Not available with serve or named
+                  pipes
+  -lock-file      path to a lock file used to prevent overlapping runs, a lock left
+                  behind by a process that is no longer running is removed
+  -lock-max-age   treat a lock older than this duration as stale even if its
+                  process is still running (default: no limit)
+  -error-report   on a fatal error write a JSON diagnostics report to this file for
+                  attaching to bug reports, containing the options with credentials
+                  redacted, whether Lair is reachable, metadata of the last 20 lines
+                  processed and a stack trace
+  -lang           language of the messages drone-bbot logs: en, es or de. Notes,
+                  issues and the -error-report are always written in English
+                  (default: en)
 `
 )
 
 func main() {
+	defer reportPanic()
 	showVersion := flag.Bool("v", false, "")
 	insecureSSL := flag.Bool("k", false, "")
-	forceHosts := flag.Bool("force-hosts", false, "")
-	tags := flag.String("tags", "", "")
+	lockFile := flag.String("lock-file", "", "")
+	lockMaxAge := flag.Duration("lock-max-age", 0, "")
+	flag.StringVar(&errorReportPath, "error-report", "", "")
+	checkAuthOnly := flag.Bool("check-auth", false, "")
+	opts := registerFlags(flag.CommandLine)
 	flag.Usage = func() {
-		fmt.Print(usage)
+		fmt.Printf(usage, defaultProbePorts)
 	}
 	flag.Parse()
-	if flag.NArg() < 2 {
-		log.Fatal("Fatal: Missing required arguments <id> and <filename>")
-	}
-	lairPID := flag.Arg(0)
-	filename := flag.Arg(1)
+	useColor = colorEnabled(os.Stderr)
 
 	if *showVersion {
 		log.Println(version)
 		os.Exit(0)
 	}
 
-	lairURL := os.Getenv("LAIR_API_SERVER")
-	if lairURL == "" {
-		log.Fatal("Fatal: Missing LAIR_API_SERVER environment variable")
+	if err := opts.prepare(); err != nil {
+		fatalf("Fatal: Invalid options. Error %s", err.Error())
 	}
 
-	u, err := url.Parse(lairURL)
-	if err != nil {
-		log.Fatalf("Fatal: Error parsing LAIR_API_SERVER URL. Error %s", err.Error())
+	if *checkAuthOnly {
+		lairPID := flag.Arg(0)
+		if lairPID == "" {
+			lairPID = os.Getenv("LAIR_ID")
+		}
+		if lairPID == "" {
+			fatalf("Fatal: Missing required argument <id>")
+		}
+		c := newLairClient(*insecureSSL, opts.airgap)
+		if err := checkAuth(c, lairPID); err != nil {
+			fatalf("Fatal: Credential check failed. Error %s", err.Error())
+		}
+		logf("Success: Credentials can export and import project %s", lairPID)
+		return
 	}
 
-	user := u.User.Username()
-	pass, _ := u.User.Password()
-	if user == "" || pass == "" {
-		log.Fatal("Fatal: Missing username and/or password")
+	switch flag.Arg(0) {
+	case "serve":
+		serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+		port := serveFlags.Int("port", 8089, "")
+		listen := serveFlags.String("listen", "127.0.0.1", "")
+		projects := serveFlags.String("projects", "", "")
+		maxBodyMB := serveFlags.Int64("max-body-mb", 256, "")
+		serveFlags.Parse(flag.Args()[1:])
+		cfg := &serveConfig{token: os.Getenv(serveTokenEnv), projects: make(map[string]bool), maxBody: *maxBodyMB << 20}
+		if cfg.token == "" {
+			fatalf("Fatal: serve requires the %s environment variable to be set", serveTokenEnv)
+		}
+		for _, id := range strings.Split(*projects, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				cfg.projects[id] = true
+			}
+		}
+		if len(cfg.projects) == 0 {
+			fatalf("Fatal: serve requires -projects, the Lair project ids it may import into")
+		}
+		if *maxBodyMB <= 0 {
+			fatalf("Fatal: -max-body-mb must be positive")
+		}
+		if opts.tui {
+			fatalf("Fatal: -tui can not be used with serve")
+		}
+		if opts.checkpoint != "" || opts.batchState != "" {
+			fatalf("Fatal: -checkpoint and -batch-state can not be used with serve")
+		}
+		c := newLairClient(*insecureSSL, opts.airgap)
+		opts.absent = newAbsentCache()
+		http.Handle("/import", importHandler(c, opts, cfg))
+		addr := net.JoinHostPort(*listen, strconv.Itoa(*port))
+		logf("Listening for imports on %s", addr)
+		server := &http.Server{Addr: addr, ReadHeaderTimeout: 30 * time.Second}
+		err := server.ListenAndServe()
+		fatalf("Fatal: Server stopped. Error %s", err.Error())
+	case "merge":
+		if flag.NArg() < 3 {
+			fatalf("Fatal: Missing required arguments <src-id> and <dst-id>")
+		}
+		var rawFilters stringList
+		mergeFlags := flag.NewFlagSet("merge", flag.ExitOnError)
+		mergeFlags.Var(&rawFilters, "filter", "")
+		mergeFlags.Parse(flag.Args()[3:])
+		filters := []hostFilter{}
+		for _, raw := range rawFilters {
+			f, err := parseHostFilter(raw)
+			if err != nil {
+				fatalf("Fatal: Invalid filter. Error %s", err.Error())
+			}
+			filters = append(filters, f)
+		}
+		c := newLairClient(*insecureSSL, opts.airgap)
+		result, err := mergeProjects(c, flag.Arg(1), flag.Arg(2), filters, opts.additiveOnly, opts.maxPayloadMB<<20)
+		if err != nil {
+			fatalf("Fatal: Merge failed. Error %s", err.Error())
+		}
+		logf("Success: %d hosts created, %d hosts updated", result.Created, result.Updated)
+		logf("Copied %d issues and %d project notes, netblocks, auth interfaces and people", result.Issues, result.Items)
+		if len(result.Locked) > 0 {
+			logf("The following hosts are tagged locked or manual and were not changed:")
+			logTable(lockedTable(result.Locked))
+		}
+		if len(result.Removed) > 0 {
+			logf("The following hosts are tagged deleted, removed or hidden and were not changed:")
+			logTable(lockedTable(result.Removed))
+		}
+		return
+	case "backfill":
+		if flag.NArg() < 3 {
+			fatalf("Fatal: Missing required arguments <id> and <filename>")
+		}
+		names, format, err := readBackfillNames(flag.Arg(2), opts.format)
+		if err != nil {
+			fatalf("Fatal: Could not read %s. Error %s", flag.Arg(2), err.Error())
+		}
+		logf("Reading %s from %s", format, flag.Arg(2))
+		c := newLairClient(*insecureSSL, opts.airgap)
+		updated, remaining, err := backfillProject(c, flag.Arg(1), flag.Arg(2), names, opts.maxPayloadMB<<20)
+		if err != nil {
+			fatalf("Fatal: Backfill failed. Error %s", err.Error())
+		}
+		logf("Success: attached hostnames to %d hosts, %d hosts still do not exist in lair", updated, len(remaining))
+		if len(remaining) > 0 {
+			logf("The following hosts had DNS names but could not be imported because they do not exist in lair:")
+			logTable(notFoundTable(remaining))
+		}
+		return
+	case "prune-tags":
+		if flag.NArg() < 2 {
+			fatalf("Fatal: Missing required argument <id>")
+		}
+		c := newLairClient(*insecureSSL, opts.airgap)
+		lines, err := pruneTags(c, flag.Arg(1), time.Now())
+		if err != nil {
+			fatalf("Fatal: Unable to list expired tags. Error %s", err.Error())
+		}
+		if len(lines) == 0 {
+			logf("No expired tags.")
+			return
+		}
+		logf("The following tags have expired, Lair's import can not remove tags so remove them in Lair:")
+		for _, line := range lines {
+			log.Println(line)
+		}
+		return
+	case "compare":
+		if flag.NArg() < 3 {
+			fatalf("Fatal: Missing required arguments <old-file> and <new-file>")
+		}
+		compareFlags := flag.NewFlagSet("compare", flag.ExitOnError)
+		deltaPID := compareFlags.String("import-delta", "", "")
+		compareFlags.Parse(flag.Args()[3:])
+		oldState, err := readScanState(flag.Arg(1))
+		if err != nil {
+			fatalf("Fatal: Could not read %s. Error %s", flag.Arg(1), err.Error())
+		}
+		newState, err := readScanState(flag.Arg(2))
+		if err != nil {
+			fatalf("Fatal: Could not read %s. Error %s", flag.Arg(2), err.Error())
+		}
+		cmp := compareScans(oldState, newState)
+		logDiff(cmp.lines())
+		if *deltaPID == "" {
+			return
+		}
+		if opts.checkpoint != "" || opts.maxDuration > 0 {
+			fatalf("Fatal: -max-duration and -checkpoint can not be used with -import-delta")
+		}
+		delta, err := deltaEvents(flag.Arg(2), cmp)
+		if err != nil {
+			fatalf("Fatal: Could not read %s. Error %s", flag.Arg(2), err.Error())
+		}
+		c := newLairClient(*insecureSSL, opts.airgap)
+		s, err := run(c, opts, *deltaPID, bytes.NewReader(delta))
+		if err != nil {
+			fatalf("Fatal: Import failed. Error %s", err.Error())
+		}
+		if s.Refused != "" {
+			logf("Refusing to import: %s. Re-run with -force to import anyway.", s.Refused)
+			os.Exit(1)
+		}
+		logf("Success: imported the delta, %d hosts created, %d hosts updated", s.HostsCreated, s.HostsUpdated)
+		return
 	}
 
-	c, err := client.New(&client.COptions{
-		User:               user,
-		Password:           pass,
-		Host:               u.Host,
-		Scheme:             u.Scheme,
-		InsecureSkipVerify: *insecureSSL,
-	})
-	if err != nil {
-		log.Fatalf("Fatal: Error setting up client: Error %s", err.Error())
+	args := flag.Args()
+	lairPID := os.Getenv("LAIR_ID")
+	if len(args) > 0 && (lairPID == "" || len(args) >= 2 && !isInputArg(args[0])) {
+		lairPID, args = args[0], args[1:]
 	}
-
-	existingProject, err := c.ExportProject(lairPID)
-	if err != nil {
-		log.Fatalf("Fatal: Unable to export project. Error %s", err.Error())
+	if lairPID == "" {
+		fatalf("Fatal: Missing required argument <id>")
+	}
+	filenames := []string{"-"}
+	if len(args) > 0 {
+		var err error
+		if filenames, err = expandInputs(args); err != nil {
+			fatalf("Fatal: Could not open file. Error %s", err.Error())
+		}
+	}
+	filename := filenames[0]
+	if len(filenames) > 1 {
+		for _, name := range filenames {
+			if name == "-" || isFIFO(name) {
+				fatalf("Fatal: stdin and named pipes can not be read together with other files")
+			}
+		}
+		if opts.maxDuration > 0 && opts.checkpoint == "" {
+			fatalf("Fatal: -max-duration with several files requires -checkpoint")
+		}
+	}
+	if filename == "-" {
+		if isTerminal(os.Stdin) {
+			fatalf("Fatal: Missing required argument <filename>, or pipe bbot output to drone-bbot")
+		}
+		if opts.maxDuration > 0 || opts.checkpoint != "" || opts.tui {
+			fatalf("Fatal: -max-duration, -checkpoint and -tui can not be used when reading from stdin")
+		}
+	}
+	if opts.maxDuration > 0 && opts.batchState != "" {
+		fatalf("Fatal: -max-duration can not be used with -batch-state")
+	}
+	if opts.maxDuration > 0 && opts.checkpoint == "" {
+		opts.checkpoint = filename + ".checkpoint"
 	}
 
-	file, err := os.Open(filename)
-	if err != nil {
-		log.Fatalf("Fatal: Could not open file. Error %s", err.Error())
+	release := func() {}
+	if *lockFile != "" {
+		var err error
+		release, err = acquireLock(*lockFile, *lockMaxAge)
+		if err != nil {
+			fatalf("Fatal: Unable to acquire lock. Error %s", err.Error())
+		}
 	}
-	defer file.Close()
+	releaseOnFatal = release
+	defer release()
 
-	scanner := bufio.NewScanner(file)
+	c := newLairClient(*insecureSSL, opts.airgap)
 
-	hostTags := []string{}
-	if *tags != "" {
-		hostTags = strings.Split(*tags, ",")
+	if opts.batchState != "" {
+		state, err := readBatchState(opts.batchState)
+		if err != nil {
+			fatalf("Fatal: Could not read batch state. Error %s", err.Error())
+		}
+		if state != nil && state.Project != lairPID {
+			fatalf("Fatal: %s holds an import into project %s", opts.batchState, state.Project)
+		}
 	}
 
-	project := &lair.Project{
-		ID:   lairPID,
-		Tool: tool,
-		Commands: []lair.Command{
-			{Tool: tool},
-		},
+	if isFIFO(filename) {
+		if opts.batchState != "" {
+			fatalf("Fatal: -batch-state can not be used with a FIFO")
+		}
+		if opts.checkpoint != "" || opts.tui || opts.detectChanges {
+			fatalf("Fatal: -max-duration, -checkpoint, -tui and -detect-changes can not be used with a FIFO")
+		}
+		opts.absent = newAbsentCache()
+		importFIFO(c, opts, lairPID, filename)
+		return
 	}
 
-	existingIPs := make(map[string]lair.Host)
-	for _, host := range existingProject.Hosts {
-		existingIPs[host.IPv4] = host
+	ins := []*input{}
+	for _, name := range filenames {
+		if info, err := os.Stat(name); err == nil && info.IsDir() {
+			preset, err := readScanPreset(name)
+			if err != nil {
+				fatalf("Fatal: Could not read the scan preset. Error %s", err.Error())
+			}
+			if opts.scanPreset == nil {
+				opts.scanPreset = preset
+			} else {
+				opts.scanPreset.add("name", preset.name)
+				opts.scanPreset.add("targets", preset.targets...)
+				opts.scanPreset.add("modules", preset.modules...)
+			}
+		}
+		in, err := openInput(name, opts.format, opts.eventFilter())
+		if err != nil {
+			fatalf("Fatal: Could not open file. Error %s", err.Error())
+		}
+		if name == "-" {
+			logf("Reading %s from stdin", in.format)
+		} else {
+			logf("Reading %s from %s", in.format, name)
+		}
+		ins = append(ins, in)
+	}
+	file := ins[0]
+	if len(ins) > 1 {
+		file = joinInputs(ins)
+		logf("Merging the events of %d files into one import", len(ins))
 	}
+	defer file.Close()
 
-	bNotFound := make(map[string][]string)
+	s, err := run(c, opts, lairPID, file)
+	if err != nil {
+		fatalf("Fatal: Import failed. Error %s", err.Error())
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	if len(opts.emailRecipients) > 0 && !opts.detectChanges {
+		if err := sendReport(opts.smtpServer, opts.emailFrom, opts.emailRecipients, s); err != nil {
+			logf("Error: Unable to email the import summary. Error %s", err.Error())
+		}
+	}
 
-		var entry map[string]interface{}
-		err = json.Unmarshal([]byte(line), &entry)
-		if err != nil {
-			log.Fatalf("Fatal: Could not parse bbot JSON. Error %s", err.Error())
-		}
-
-		if entry["type"] == "DNS_NAME" {
-			dnsName := entry["host"].(string)
-			resolvedHosts := entry["resolved_hosts"].([]interface{})
-			for _, ip := range resolvedHosts {
-				ipStr := ip.(string)
-
-				if existingHost, found := existingIPs[ipStr]; found {
-					existingHost.Hostnames = append(existingHost.Hostnames, dnsName)
-					existingHost.LastModifiedBy = tool
-					existingHost.Tags = append(existingHost.Tags, hostTags...)
-					existingIPs[ipStr] = existingHost
-				} else {
-					if *forceHosts {
-						project.Hosts = append(project.Hosts, lair.Host{
-							IPv4:           ipStr,
-							Hostnames:      []string{dnsName},
-							Tags:           hostTags,
-							LastModifiedBy: tool,
-						})
-					} else {
-						bNotFound[ipStr] = append(bNotFound[ipStr], dnsName)
-					}
-				}
-			}
+	if opts.detectChanges {
+		if !s.Changed {
+			logf("No changes detected.")
+			return
 		}
+		logf("Changes detected: %d hosts would be created, %d hosts would be updated", s.HostsCreated, s.HostsUpdated)
+		file.Close()
+		release()
+		os.Exit(2)
 	}
 
-	for _, host := range existingIPs {
-		project.Hosts = append(project.Hosts, host)
+	if s.Refused != "" {
+		logf("Refusing to import: %s. Re-run with -force to import anyway.", s.Refused)
+		logf("Dry run: %d hosts would be created, %d hosts would be updated", s.HostsCreated, s.HostsUpdated)
+		file.Close()
+		release()
+		os.Exit(1)
 	}
 
-	if len(project.Hosts) > 0 {
-		options := &client.DOptions{}
-		res, err := c.ImportProject(options, project)
-		if err != nil {
-			log.Fatalf("Fatal: Unable to import project. Error %s", err)
-		}
-		defer res.Body.Close()
-		log.Println("Success: Operation completed successfully")
+	if s.Imported {
+		logf("Success: Operation completed successfully")
 	} else {
-		log.Println("No new hosts were imported.")
+		logf("No new hosts were imported.")
+	}
+
+	if s.Partial {
+		logf("Partial: -max-duration reached, re-run with the same file to continue from %s", opts.checkpoint)
+	}
+
+	if s.Metrics != nil {
+		logTable(s.Metrics.table())
 	}
 
-	if len(bNotFound) > 0 {
-		log.Println("The following hosts had DNS names but could not be imported because they do not exist in lair:")
-		for ip, dnsNames := range bNotFound {
-			log.Printf("IP: %s, DNS Names: %v\n", ip, dnsNames)
+	if len(s.NotFound) > 0 && opts.notFoundNote {
+		logf("%d hosts had DNS names but do not exist in lair, they are listed in a project note", len(s.NotFound))
+	} else if len(s.NotFound) > 0 {
+		logf("The following hosts had DNS names but could not be imported because they do not exist in lair:")
+		logTable(notFoundTable(s.NotFound))
+	}
+
+	if s.QA != nil {
+		logf("QA: %d of %d sampled hostnames did not resolve to their host (%.0f%%), %d did not resolve",
+			s.QA.Mismatched+s.QA.Unresolved, s.QA.Sampled, s.QA.rate(), s.QA.Unresolved)
+		for _, problem := range s.QA.Problems {
+			log.Println(problem)
 		}
 	}
+
+	if len(s.Locked) > 0 {
+		logf("The following hosts are tagged locked or manual and were not changed:")
+		logTable(lockedTable(s.Locked))
+	}
+
+	if len(s.Removed) > 0 {
+		logf("The following hosts are tagged deleted, removed or hidden and were not changed:")
+		logTable(lockedTable(s.Removed))
+	}
+
+	if s.Partial {
+		file.Close()
+		release()
+		os.Exit(3)
+	}
+}
+
+// newLairClient sets up a Lair API client from the LAIR_API_SERVER environment
+// variable, exiting if it is missing or invalid.
+func newLairClient(insecureSSL, airgap bool) *client.C {
+	lairURL := os.Getenv("LAIR_API_SERVER")
+	if lairURL == "" {
+		fatalf("Fatal: Missing LAIR_API_SERVER environment variable")
+	}
+
+	u, err := url.Parse(lairURL)
+	if err != nil {
+		fatalf("Fatal: Error parsing LAIR_API_SERVER URL. Error %s", err.Error())
+	}
+
+	user := u.User.Username()
+	pass, _ := u.User.Password()
+	if user == "" || pass == "" {
+		fatalf("Fatal: Missing username and/or password")
+	}
+
+	if airgap {
+		restrictDefaultTransport(u.Hostname())
+	}
+
+	c, err := client.New(&client.COptions{
+		User:               user,
+		Password:           pass,
+		Host:               u.Host,
+		Scheme:             u.Scheme,
+		InsecureSkipVerify: insecureSSL,
+	})
+	if err != nil {
+		fatalf("Fatal: Error setting up client: Error %s", err.Error())
+	}
+	return c
 }
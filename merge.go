@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// hostFilter selects hosts by domain, ip or tag.
+type hostFilter struct {
+	field string
+	value string
+}
+
+// parseHostFilter parses filters of the form domain=<domain>, ip=<ip, CIDR or
+// wildcard pattern> and tag=<tag>.
+func parseHostFilter(s string) (hostFilter, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return hostFilter{}, fmt.Errorf("invalid filter %q", s)
+	}
+	f := hostFilter{field: parts[0], value: strings.ToLower(parts[1])}
+	switch f.field {
+	case "domain", "tag":
+	case "ip":
+		if strings.Contains(f.value, "/") {
+			if _, _, err := net.ParseCIDR(f.value); err != nil {
+				return hostFilter{}, fmt.Errorf("invalid CIDR %q", f.value)
+			}
+		}
+	default:
+		return hostFilter{}, fmt.Errorf("unknown filter field %q", f.field)
+	}
+	return f, nil
+}
+
+func (f hostFilter) matches(host lair.Host) bool {
+	switch f.field {
+	case "domain":
+		for _, name := range host.Hostnames {
+			name = strings.ToLower(name)
+			if name == f.value || strings.HasSuffix(name, "."+f.value) {
+				return true
+			}
+		}
+	case "tag":
+		for _, tag := range host.Tags {
+			if strings.ToLower(tag) == f.value {
+				return true
+			}
+		}
+	case "ip":
+		if strings.Contains(f.value, "/") {
+			_, network, _ := net.ParseCIDR(f.value)
+			ip := net.ParseIP(host.IPv4)
+			return ip != nil && network.Contains(ip)
+		}
+		ok, _ := path.Match(f.value, host.IPv4)
+		return ok
+	}
+	return false
+}
+
+// mergeResult is the outcome of mergeProjects: the number of hosts created and
+// updated, the number of issues and project items copied, and the changes
+// withheld from locked and removed hosts keyed by IP.
+type mergeResult struct {
+	Created int
+	Updated int
+	Issues  int
+	Items   int
+	Locked  map[string][]string
+	Removed map[string][]string
+}
+
+// mergeProjects copies the hosts in project srcID that match every filter into
+// project dstID. Hosts that already exist in dstID by IP gain the hostnames,
+// tags, notes, services, service notes and web directories they are missing,
+// unless they are locked or removed or additiveOnly is set. The issues of the
+// copied hosts follow them, restricted to those hosts, and the project notes,
+// netblocks, auth interfaces and people that dstID lacks are copied as well.
+// Imports larger than maxPayload bytes are split.
+func mergeProjects(c *client.C, srcID, dstID string, filters []hostFilter, additiveOnly bool, maxPayload int) (mergeResult, error) {
+	result := mergeResult{Locked: make(map[string][]string), Removed: make(map[string][]string)}
+	src, err := c.ExportProject(srcID)
+	if err != nil {
+		return result, fmt.Errorf("unable to export project %s: %s", srcID, err.Error())
+	}
+	dst, err := c.ExportProject(dstID)
+	if err != nil {
+		return result, fmt.Errorf("unable to export project %s: %s", dstID, err.Error())
+	}
+
+	existing := make(map[string]lair.Host)
+	for _, host := range dst.Hosts {
+		existing[host.IPv4] = host
+	}
+
+	project := &lair.Project{
+		ID:   dstID,
+		Tool: lastModifiedBy,
+		Commands: []lair.Command{
+			{Tool: tool, Command: "merge " + srcID},
+		},
+	}
+	moved := make(map[string]bool)
+	skipped := 0
+	for _, host := range src.Hosts {
+		if !matchesAll(host, filters) {
+			continue
+		}
+		dstHost, found := existing[host.IPv4]
+		if !found {
+			project.Hosts = append(project.Hosts, copyHost(host))
+			moved[host.IPv4] = true
+			result.Created++
+			continue
+		}
+		if !isLockedHost(dstHost) && !isRemovedHost(dstHost) {
+			moved[host.IPv4] = true
+		}
+		before := dstHost
+		before.Services = append([]lair.Service{}, dstHost.Services...)
+		changed := mergeHost(&dstHost, host.Hostnames, host.Tags)
+		if mergeHostDetails(&dstHost, host) {
+			dstHost.LastModifiedBy = lastModifiedBy
+			changed = true
+		}
+		switch {
+		case !changed:
+		case isLockedHost(before):
+			result.Locked[host.IPv4] = hostChanges(before, dstHost)
+		case isRemovedHost(before):
+			result.Removed[host.IPv4] = hostChanges(before, dstHost)
+		case additiveOnly:
+			skipped++
+		default:
+			project.Hosts = append(project.Hosts, dstHost)
+			result.Updated++
+		}
+	}
+	if skipped > 0 {
+		logf("Skipped changes to %d existing hosts with -additive-only", skipped)
+	}
+
+	result.Issues = copyIssues(project, src.Issues, dst.Issues, moved, len(filters) == 0, additiveOnly)
+	result.Items = copyProjectItems(project, &src, &dst)
+
+	if len(project.Hosts) == 0 && len(project.Issues) == 0 && result.Items == 0 {
+		return result, nil
+	}
+	if err := importProject(c, project, maxPayload, "", ""); err != nil {
+		return result, fmt.Errorf("unable to import project %s: %s", dstID, err.Error())
+	}
+	return result, nil
+}
+
+// copyIssues adds the issues of src that affect a moved host to project, with
+// their hosts restricted to the moved ones. Issues without hosts are copied
+// only when every host is, and with additiveOnly issues whose plugin ID is
+// already in dst are skipped. It returns the number of issues added.
+func copyIssues(project *lair.Project, src, dst []lair.Issue, moved map[string]bool, all, additiveOnly bool) int {
+	known := make(map[string]bool)
+	for _, issue := range dst {
+		for _, id := range issue.PluginIDs {
+			known[id.ID] = true
+		}
+	}
+	skipped := 0
+	added := 0
+	for _, issue := range src {
+		hosts := []lair.IssueHost{}
+		for _, host := range issue.Hosts {
+			if moved[host.IPv4] {
+				hosts = append(hosts, host)
+			}
+		}
+		if len(hosts) == 0 && (len(issue.Hosts) > 0 || !all) {
+			continue
+		}
+		if additiveOnly && len(issue.PluginIDs) > 0 && known[issue.PluginIDs[0].ID] {
+			skipped++
+			continue
+		}
+		issue.ID, issue.ProjectID = "", ""
+		issue.Hosts = hosts
+		issue.LastModifiedBy = lastModifiedBy
+		project.Issues = append(project.Issues, issue)
+		added++
+	}
+	if skipped > 0 {
+		logf("Skipped %d issues already in the project with -additive-only", skipped)
+	}
+	return added
+}
+
+// copyProjectItems adds the project notes, netblocks, auth interfaces and
+// people of src that dst does not already have, by title, CIDR, URL and
+// person, to project. Lair inserts every imported auth interface and person
+// without merging, so existing ones must be left out. It returns the number of
+// items added.
+func copyProjectItems(project *lair.Project, src, dst *lair.Project) int {
+	added := 0
+	for _, note := range src.Notes {
+		if !hasNote(dst.Notes, note.Title) {
+			project.Notes = append(project.Notes, note)
+			added++
+		}
+	}
+	cidrs := make(map[string]bool)
+	for _, netblock := range dst.Netblocks {
+		cidrs[netblock.CIDR] = true
+	}
+	for _, netblock := range src.Netblocks {
+		if !cidrs[netblock.CIDR] {
+			netblock.ID, netblock.ProjectID = "", ""
+			project.Netblocks = append(project.Netblocks, netblock)
+			added++
+		}
+	}
+	urls := make(map[string]bool)
+	for _, ai := range dst.AuthInterfaces {
+		urls[ai.URL] = true
+	}
+	for _, ai := range src.AuthInterfaces {
+		if !urls[ai.URL] {
+			urls[ai.URL] = true
+			ai.ID, ai.ProjectID = "", ""
+			project.AuthInterfaces = append(project.AuthInterfaces, ai)
+			added++
+		}
+	}
+	people := make(map[string]bool)
+	for _, person := range dst.People {
+		people[personKey(person)] = true
+	}
+	for _, person := range src.People {
+		if key := personKey(person); !people[key] {
+			people[key] = true
+			person.ID, person.ProjectID = "", ""
+			project.People = append(project.People, person)
+			added++
+		}
+	}
+	return added
+}
+
+func matchesAll(host lair.Host, filters []hostFilter) bool {
+	for _, f := range filters {
+		if !f.matches(host) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeHostDetails adds the notes, services, service notes and web directories
+// of src that host does not already have, by title, port and protocol, and path
+// and port, reporting whether any were added.
+func mergeHostDetails(host *lair.Host, src lair.Host) bool {
+	added := false
+	for _, note := range src.Notes {
+		if !hasNote(host.Notes, note.Title) {
+			host.Notes = append(host.Notes, note)
+			added = true
+		}
+	}
+	for _, service := range src.Services {
+		var existing *lair.Service
+		for i := range host.Services {
+			if host.Services[i].Port == service.Port && host.Services[i].Protocol == service.Protocol {
+				existing = &host.Services[i]
+				break
+			}
+		}
+		if existing == nil {
+			service.ID, service.ProjectID, service.HostID = "", "", ""
+			host.Services = append(host.Services, service)
+			added = true
+			continue
+		}
+		for _, note := range service.Notes {
+			if !hasNote(existing.Notes, note.Title) {
+				existing.Notes = append(existing.Notes, note)
+				added = true
+			}
+		}
+	}
+	for _, dir := range src.WebDirectories {
+		found := false
+		for _, existing := range host.WebDirectories {
+			if existing.Path == dir.Path && existing.Port == dir.Port {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dir.ID, dir.ProjectID, dir.HostID = "", "", ""
+			host.WebDirectories = append(host.WebDirectories, dir)
+			added = true
+		}
+	}
+	return added
+}
+
+// copyHost returns host with the identifiers of its source project removed so
+// that it can be imported into another project.
+func copyHost(host lair.Host) lair.Host {
+	host.ID = ""
+	host.ProjectID = ""
+	host.LastModifiedBy = lastModifiedBy
+	services := []lair.Service{}
+	for _, service := range host.Services {
+		service.ID, service.ProjectID, service.HostID = "", "", ""
+		services = append(services, service)
+	}
+	host.Services = services
+	webDirectories := []lair.WebDirectory{}
+	for _, dir := range host.WebDirectories {
+		dir.ID, dir.ProjectID, dir.HostID = "", "", ""
+		webDirectories = append(webDirectories, dir)
+	}
+	host.WebDirectories = webDirectories
+	return host
+}
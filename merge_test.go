@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lair-framework/go-lair"
+)
+
+func TestMergeProjects(t *testing.T) {
+	src := lair.Project{
+		ID: "src",
+		Hosts: []lair.Host{
+			{ID: "h1", IPv4: "192.0.2.1", Hostnames: []string{"www.example.com"}},
+			{ID: "h2", IPv4: "192.0.2.2", Hostnames: []string{"mail.example.com"}},
+			{ID: "h3", IPv4: "198.51.100.1", Hostnames: []string{"www.example.org"}},
+		},
+		Issues: []lair.Issue{
+			{ID: "i1", Title: "Shared", PluginIDs: []lair.PluginID{{Tool: tool, ID: "shared"}}, Hosts: []lair.IssueHost{{IPv4: "192.0.2.1"}, {IPv4: "198.51.100.1"}}},
+			{ID: "i2", Title: "Known", PluginIDs: []lair.PluginID{{Tool: tool, ID: "known"}}, Hosts: []lair.IssueHost{{IPv4: "192.0.2.2"}}},
+			{ID: "i3", Title: "Other", PluginIDs: []lair.PluginID{{Tool: tool, ID: "other"}}, Hosts: []lair.IssueHost{{IPv4: "198.51.100.1"}}},
+		},
+		Notes:          []lair.Note{{Title: "Scope", Content: "example.com"}, {Title: "Existing", Content: "src"}},
+		Netblocks:      []lair.Netblock{{ID: "n1", CIDR: "192.0.2.0/24"}, {ID: "n2", CIDR: "198.51.100.0/24"}},
+		AuthInterfaces: []lair.AuthInterface{{ID: "a1", URL: "https://www.example.com/login"}, {ID: "a2", URL: "https://vpn.example.com/"}},
+		People:         []lair.Person{{ID: "p1", DisplayName: "Alice", Emails: []string{"alice@example.com"}}, {ID: "p2", DisplayName: "Bob"}},
+	}
+	dst := lair.Project{
+		ID:             "dst",
+		Hosts:          []lair.Host{{ID: "d2", IPv4: "192.0.2.2", Hostnames: []string{"mail.example.com"}}},
+		Issues:         []lair.Issue{{ID: "d1", Title: "Known", PluginIDs: []lair.PluginID{{Tool: tool, ID: "known"}}}},
+		Notes:          []lair.Note{{Title: "Existing", Content: "dst"}},
+		Netblocks:      []lair.Netblock{{CIDR: "198.51.100.0/24"}},
+		AuthInterfaces: []lair.AuthInterface{{URL: "https://vpn.example.com/"}},
+		People:         []lair.Person{{DisplayName: "Bob"}},
+	}
+	filter, _ := parseHostFilter("domain=example.com")
+
+	tests := []struct {
+		name         string
+		filters      []hostFilter
+		additiveOnly bool
+		wantIssues   map[string]int
+	}{
+		{"all hosts", nil, false, map[string]int{"Shared": 2, "Known": 1, "Other": 1}},
+		{"filtered", []hostFilter{filter}, false, map[string]int{"Shared": 1, "Known": 1}},
+		{"additive only", []hostFilter{filter}, true, map[string]int{"Shared": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, c := newFakeLair(t)
+			f.projects["src"], f.projects["dst"] = src, dst
+			result, err := mergeProjects(c, "src", "dst", tt.filters, tt.additiveOnly, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(f.imports) != 1 {
+				t.Fatalf("sent %d imports, want 1", len(f.imports))
+			}
+			imported := f.imports[0]
+			if result.Issues != len(tt.wantIssues) || len(imported.Issues) != len(tt.wantIssues) {
+				t.Fatalf("copied %d issues, want %d: %+v", result.Issues, len(tt.wantIssues), imported.Issues)
+			}
+			for _, issue := range imported.Issues {
+				if issue.ID != "" || len(issue.Hosts) != tt.wantIssues[issue.Title] {
+					t.Errorf("issue %s has id %q and %d hosts, want no id and %d hosts", issue.Title, issue.ID, len(issue.Hosts), tt.wantIssues[issue.Title])
+				}
+			}
+			if result.Items != 4 || len(imported.Notes) != 1 || len(imported.Netblocks) != 1 || len(imported.AuthInterfaces) != 1 || len(imported.People) != 1 {
+				t.Errorf("copied %d items: notes %+v, netblocks %+v, auth interfaces %+v, people %+v, want one of each that dst lacks",
+					result.Items, imported.Notes, imported.Netblocks, imported.AuthInterfaces, imported.People)
+			}
+			if imported.Netblocks[0].ID != "" || imported.AuthInterfaces[0].ID != "" || imported.People[0].ID != "" {
+				t.Errorf("copied items keep the ids of the source project")
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// languages holds the translations of the messages drone-bbot logs, keyed by
+// the -lang code and the English format string. Messages without a
+// translation are logged in English, as are the error reports written by
+// -error-report.
+var languages = map[string]map[string]string{
+	"en": {},
+	"es": {
+		"%d hosts had DNS names but do not exist in lair, they are listed in a project note":             "%d hosts tenían nombres DNS pero no existen en lair, se listan en una nota del proyecto",
+		"%d hosts that do not exist in lair were already reported":                                       "%d hosts que no existen en lair ya se habían informado",
+		"Added %q prefixed copies of legacy tags, remove the originals in Lair: %s":                      "Se añadieron copias con el prefijo %q de las etiquetas antiguas, elimine las originales en Lair: %s",
+		"Changes detected: %d hosts would be created, %d hosts would be updated":                         "Cambios detectados: se crearían %d hosts y se actualizarían %d hosts",
+		"Copied %d issues and %d project notes, netblocks, auth interfaces and people":                   "Se copiaron %d vulnerabilidades y %d notas del proyecto, netblocks, interfaces de autenticación y personas",
+		"Created %d hosts with services reported by %s":                                                  "Se crearon %d hosts con servicios informados por %s",
+		"Created %d low-confidence hosts with at least %d DNS names":                                     "Se crearon %d hosts de baja confianza con al menos %d nombres DNS",
+		"Dry run: %d hosts would be created, %d hosts would be updated":                                  "Simulación: se crearían %d hosts y se actualizarían %d hosts",
+		"Error: Import into project %s failed. Error %s":                                                 "Error: Falló la importación en el proyecto %s. Error %s",
+		"Error: Unable to email the import summary. Error %s":                                            "Error: No se pudo enviar por correo el resumen de la importación. Error %s",
+		"Error: Unable to look up %s. Error %s":                                                          "Error: No se pudo consultar %s. Error %s",
+		"Error: Unable to open a ticket for %s. Error %s":                                                "Error: No se pudo abrir un ticket para %s. Error %s",
+		"Error: Unable to read from %s. Error %s":                                                        "Error: No se pudo leer de %s. Error %s",
+		"Error: Unable to upload screenshot %s. Error %s":                                                "Error: No se pudo subir la captura de pantalla %s. Error %s",
+		"Error: Unable to write error report. Error %s":                                                  "Error: No se pudo escribir el informe de error. Error %s",
+		"Events by type in this import: %s":                                                              "Eventos por tipo en esta importación: %s",
+		"Fatal: %s holds an import into project %s":                                                      "Fatal: %s contiene una importación al proyecto %s",
+		"Fatal: -batch-state can not be used with a FIFO":                                                "Fatal: -batch-state no se puede usar con un FIFO",
+		"Fatal: -checkpoint and -batch-state can not be used with serve":                                 "Fatal: -checkpoint y -batch-state no se pueden usar con serve",
+		"Fatal: -max-body-mb must be positive":                                                           "Fatal: -max-body-mb debe ser positivo",
+		"Fatal: -max-duration and -checkpoint can not be used with -import-delta":                        "Fatal: -max-duration y -checkpoint no se pueden usar con -import-delta",
+		"Fatal: -max-duration can not be used with -batch-state":                                         "Fatal: -max-duration no se puede usar con -batch-state",
+		"Fatal: -max-duration with several files requires -checkpoint":                                   "Fatal: -max-duration con varios archivos requiere -checkpoint",
+		"Fatal: -max-duration, -checkpoint and -tui can not be used when reading from stdin":             "Fatal: -max-duration, -checkpoint y -tui no se pueden usar al leer de stdin",
+		"Fatal: -max-duration, -checkpoint, -tui and -detect-changes can not be used with a FIFO":        "Fatal: -max-duration, -checkpoint, -tui y -detect-changes no se pueden usar con un FIFO",
+		"Fatal: -tui can not be used with serve":                                                         "Fatal: -tui no se puede usar con serve",
+		"Fatal: Backfill failed. Error %s":                                                               "Fatal: Falló el relleno. Error %s",
+		"Fatal: Could not open file. Error %s":                                                           "Fatal: No se pudo abrir el archivo. Error %s",
+		"Fatal: Could not read %s. Error %s":                                                             "Fatal: No se pudo leer %s. Error %s",
+		"Fatal: Could not read batch state. Error %s":                                                    "Fatal: No se pudo leer el estado de los lotes. Error %s",
+		"Fatal: Could not read the scan preset. Error %s":                                                "Fatal: No se pudo leer el preset del escaneo. Error %s",
+		"Fatal: Credential check failed. Error %s":                                                       "Fatal: Falló la comprobación de credenciales. Error %s",
+		"Fatal: Error parsing LAIR_API_SERVER URL. Error %s":                                             "Fatal: Error al analizar la URL de LAIR_API_SERVER. Error %s",
+		"Fatal: Error setting up client: Error %s":                                                       "Fatal: Error al configurar el cliente: Error %s",
+		"Fatal: Import failed. Error %s":                                                                 "Fatal: Falló la importación. Error %s",
+		"Fatal: Invalid filter. Error %s":                                                                "Fatal: Filtro no válido. Error %s",
+		"Fatal: Invalid options. Error %s":                                                               "Fatal: Opciones no válidas. Error %s",
+		"Fatal: Merge failed. Error %s":                                                                  "Fatal: Falló la fusión. Error %s",
+		"Fatal: Missing LAIR_API_SERVER environment variable":                                            "Fatal: Falta la variable de entorno LAIR_API_SERVER",
+		"Fatal: Missing required argument <filename>, or pipe bbot output to drone-bbot":                 "Fatal: Falta el argumento obligatorio <filename>, o redirija la salida de bbot a drone-bbot",
+		"Fatal: Missing required argument <id>":                                                          "Fatal: Falta el argumento obligatorio <id>",
+		"Fatal: Missing required arguments <id> and <filename>":                                          "Fatal: Faltan los argumentos obligatorios <id> y <filename>",
+		"Fatal: Missing required arguments <old-file> and <new-file>":                                    "Fatal: Faltan los argumentos obligatorios <old-file> y <new-file>",
+		"Fatal: Missing required arguments <src-id> and <dst-id>":                                        "Fatal: Faltan los argumentos obligatorios <src-id> y <dst-id>",
+		"Fatal: Missing username and/or password":                                                        "Fatal: Falta el usuario y/o la contraseña",
+		"Fatal: Server stopped. Error %s":                                                                "Fatal: El servidor se detuvo. Error %s",
+		"Fatal: Unable to acquire lock. Error %s":                                                        "Fatal: No se pudo obtener el bloqueo. Error %s",
+		"Fatal: Unable to list expired tags. Error %s":                                                   "Fatal: No se pudieron listar las etiquetas caducadas. Error %s",
+		"Fatal: serve requires -projects, the Lair project ids it may import into":                       "Fatal: serve requiere -projects, los ids de los proyectos de Lair en los que puede importar",
+		"Fatal: serve requires the %s environment variable to be set":                                    "Fatal: serve requiere que la variable de entorno %s esté definida",
+		"Fatal: stdin and named pipes can not be read together with other files":                         "Fatal: stdin y las tuberías con nombre no se pueden leer junto con otros archivos",
+		"Import payload is %d bytes, sending it in %d parts":                                             "La importación ocupa %d bytes, se envía en %d partes",
+		"Imported into project %s, %d hosts created, %d hosts updated":                                   "Importado en el proyecto %s, %d hosts creados, %d hosts actualizados",
+		"Listening for imports on %s":                                                                    "Esperando importaciones en %s",
+		"Looking up %d hosts that do not exist in lair in %s":                                            "Consultando %d hosts que no existen en lair en %s",
+		"Looking up %d new hostnames in %s":                                                              "Consultando %d nombres de host nuevos en %s",
+		"Merging the events of %d files into one import":                                                 "Combinando los eventos de %d archivos en una sola importación",
+		"No changes detected.":                                                                           "No se detectaron cambios.",
+		"No expired tags.":                                                                               "No hay etiquetas caducadas.",
+		"No new hosts were imported.":                                                                    "No se importaron hosts nuevos.",
+		"Partial: -max-duration reached, re-run with the same file to continue from %s":                  "Parcial: se alcanzó -max-duration, vuelva a ejecutar con el mismo archivo para continuar desde %s",
+		"Probing %d hosts that do not exist in lair":                                                     "Sondeando %d hosts que no existen en lair",
+		"QA: %d of %d sampled hostnames did not resolve to their host (%.0f%%), %d did not resolve":      "QA: %d de %d nombres de host de la muestra no resolvieron a su host (%.0f%%), %d no resolvieron",
+		"Read the input in %s, the import took %s":                                                       "Entrada leída en %s, la importación tardó %s",
+		"Reading %s from %s":                                                                             "Leyendo %s de %s",
+		"Reading %s from stdin":                                                                          "Leyendo %s de stdin",
+		"Recently changed CNAME records that may allow a takeover: %s":                                   "Registros CNAME cambiados recientemente que podrían permitir una toma de control: %s",
+		"Recorded email addresses for %d domains":                                                        "Se registraron direcciones de correo de %d dominios",
+		"Refused import into project %s: %s":                                                             "Importación rechazada en el proyecto %s: %s",
+		"Refusing to import: %s. Re-run with -force to import anyway.":                                   "Importación rechazada: %s. Vuelva a ejecutar con -force para importar de todos modos.",
+		"Resuming from checkpoint, skipping the first %d lines":                                          "Reanudando desde el punto de control, omitiendo las primeras %d líneas",
+		"Resuming import, sending %d of %d parts from %s":                                                "Reanudando la importación, enviando %d de %d partes desde %s",
+		"Skipped %d DNS names that only resolve to IPv6, see -ipv6-policy: %s":                           "Se omitieron %d nombres DNS que solo resuelven a IPv6, consulte -ipv6-policy: %s",
+		"Skipped %d issues already in the project with -additive-only":                                   "Se omitieron %d vulnerabilidades que ya están en el proyecto con -additive-only",
+		"Skipped %d new hosts with cloud provider IPs: %s":                                               "Se omitieron %d hosts nuevos con IPs de proveedores cloud: %s",
+		"Skipped -track-seen, this run did not read every DNS_NAME event of the input":                   "Se omitió -track-seen, esta ejecución no leyó todos los eventos DNS_NAME de la entrada",
+		"Skipped changes to %d existing hosts with -additive-only":                                       "Se omitieron cambios en %d hosts existentes con -additive-only",
+		"Skipped hostnames outside of the scope, use -allow-foreign-domains to import them: %s":          "Se omitieron nombres de host fuera del alcance, use -allow-foreign-domains para importarlos: %s",
+		"Skipped hosts for %d domains that exceeded -max-hosts-per-domain":                               "Se omitieron hosts de %d dominios que superaron -max-hosts-per-domain",
+		"Skipped open ports on %d hosts that do not exist in lair":                                       "Se omitieron puertos abiertos en %d hosts que no existen en lair",
+		"Skipped probing %d hosts that do not exist in lair and are outside of the scope":                "No se sondearon %d hosts que no existen en lair y están fuera del alcance",
+		"Success: %d hosts created, %d hosts updated":                                                    "Éxito: %d hosts creados, %d hosts actualizados",
+		"Success: Credentials can export and import project %s":                                          "Éxito: Las credenciales pueden exportar e importar el proyecto %s",
+		"Success: Operation completed successfully":                                                      "Éxito: Operación completada correctamente",
+		"Success: attached hostnames to %d hosts, %d hosts still do not exist in lair":                   "Éxito: se añadieron nombres de host a %d hosts, %d hosts todavía no existen en lair",
+		"Success: imported the delta, %d hosts created, %d hosts updated":                                "Éxito: se importó la diferencia, %d hosts creados, %d hosts actualizados",
+		"The following hosts are tagged deleted, removed or hidden and were not changed:":                "Los siguientes hosts tienen la etiqueta deleted, removed o hidden y no se modificaron:",
+		"The following hosts are tagged locked or manual and were not changed:":                          "Los siguientes hosts tienen la etiqueta locked o manual y no se modificaron:",
+		"The following hosts had DNS names but could not be imported because they do not exist in lair:": "Los siguientes hosts tenían nombres DNS pero no se pudieron importar porque no existen en lair:",
+		"The following tags have expired, Lair's import can not remove tags so remove them in Lair:":     "Las siguientes etiquetas han caducado, la importación de Lair no puede eliminar etiquetas, elimínelas en Lair:",
+		"The import finished %s after bbot's newest event":                                               "La importación terminó %s después del evento más reciente de bbot",
+		"Uploaded %d screenshots to Lair":                                                                "Se subieron %d capturas de pantalla a Lair",
+		"Waiting for a writer on %s":                                                                     "Esperando a un escritor en %s",
+		"Warning: Unable to write evidence to %s. Error %s":                                              "Advertencia: No se pudo escribir la evidencia en %s. Error %s",
+		"Warning: an item of %d bytes is larger than -max-payload-mb and is sent on its own":             "Advertencia: un elemento de %d bytes supera -max-payload-mb y se envía por separado",
+		"Warning: no -scope or bbot targets, -force-hosts imports hostnames of any domain":               "Advertencia: sin -scope ni objetivos de bbot, -force-hosts importa nombres de host de cualquier dominio",
+		"Warning: the import no longer matches the parts recorded in %s, sending every part":             "Advertencia: la importación ya no coincide con las partes registradas en %s, se envían todas las partes",
+		"Wrote %d URLs to %s and %s":                                                                     "Se escribieron %d URLs en %s y %s",
+		"Wrote %d issues below -min-severity to %s":                                                      "Se escribieron %d vulnerabilidades por debajo de -min-severity en %s",
+		"Wrote error report to %s":                                                                       "Se escribió el informe de error en %s",
+	},
+	"de": {
+		"%d hosts had DNS names but do not exist in lair, they are listed in a project note":             "%d Hosts hatten DNS-Namen, existieren aber nicht in lair, sie sind in einer Projektnotiz aufgeführt",
+		"%d hosts that do not exist in lair were already reported":                                       "%d Hosts, die nicht in lair existieren, wurden bereits gemeldet",
+		"Added %q prefixed copies of legacy tags, remove the originals in Lair: %s":                      "Kopien der alten Tags mit dem Präfix %q hinzugefügt, entfernen Sie die Originale in Lair: %s",
+		"Changes detected: %d hosts would be created, %d hosts would be updated":                         "Änderungen erkannt: %d Hosts würden erstellt, %d Hosts würden aktualisiert",
+		"Copied %d issues and %d project notes, netblocks, auth interfaces and people":                   "%d Schwachstellen und %d Projektnotizen, Netzblöcke, Auth-Schnittstellen und Personen kopiert",
+		"Created %d hosts with services reported by %s":                                                  "%d Hosts mit von %s gemeldeten Diensten erstellt",
+		"Created %d low-confidence hosts with at least %d DNS names":                                     "%d Hosts mit geringer Zuverlässigkeit und mindestens %d DNS-Namen erstellt",
+		"Dry run: %d hosts would be created, %d hosts would be updated":                                  "Probelauf: %d Hosts würden erstellt, %d Hosts würden aktualisiert",
+		"Error: Import into project %s failed. Error %s":                                                 "Fehler: Import in Projekt %s fehlgeschlagen. Fehler %s",
+		"Error: Unable to email the import summary. Error %s":                                            "Fehler: Die Importzusammenfassung konnte nicht per E-Mail gesendet werden. Fehler %s",
+		"Error: Unable to look up %s. Error %s":                                                          "Fehler: %s konnte nicht abgefragt werden. Fehler %s",
+		"Error: Unable to open a ticket for %s. Error %s":                                                "Fehler: Für %s konnte kein Ticket erstellt werden. Fehler %s",
+		"Error: Unable to read from %s. Error %s":                                                        "Fehler: Von %s konnte nicht gelesen werden. Fehler %s",
+		"Error: Unable to upload screenshot %s. Error %s":                                                "Fehler: Der Screenshot %s konnte nicht hochgeladen werden. Fehler %s",
+		"Error: Unable to write error report. Error %s":                                                  "Fehler: Der Fehlerbericht konnte nicht geschrieben werden. Fehler %s",
+		"Events by type in this import: %s":                                                              "Ereignisse nach Typ in diesem Import: %s",
+		"Fatal: %s holds an import into project %s":                                                      "Fatal: %s enthält einen Import in das Projekt %s",
+		"Fatal: -batch-state can not be used with a FIFO":                                                "Fatal: -batch-state kann nicht mit einem FIFO verwendet werden",
+		"Fatal: -checkpoint and -batch-state can not be used with serve":                                 "Fatal: -checkpoint und -batch-state können nicht mit serve verwendet werden",
+		"Fatal: -max-body-mb must be positive":                                                           "Fatal: -max-body-mb muss positiv sein",
+		"Fatal: -max-duration and -checkpoint can not be used with -import-delta":                        "Fatal: -max-duration und -checkpoint können nicht mit -import-delta verwendet werden",
+		"Fatal: -max-duration can not be used with -batch-state":                                         "Fatal: -max-duration kann nicht mit -batch-state verwendet werden",
+		"Fatal: -max-duration with several files requires -checkpoint":                                   "Fatal: -max-duration mit mehreren Dateien erfordert -checkpoint",
+		"Fatal: -max-duration, -checkpoint and -tui can not be used when reading from stdin":             "Fatal: -max-duration, -checkpoint und -tui können beim Lesen von stdin nicht verwendet werden",
+		"Fatal: -max-duration, -checkpoint, -tui and -detect-changes can not be used with a FIFO":        "Fatal: -max-duration, -checkpoint, -tui und -detect-changes können nicht mit einem FIFO verwendet werden",
+		"Fatal: -tui can not be used with serve":                                                         "Fatal: -tui kann nicht mit serve verwendet werden",
+		"Fatal: Backfill failed. Error %s":                                                               "Fatal: Nachtragen fehlgeschlagen. Fehler %s",
+		"Fatal: Could not open file. Error %s":                                                           "Fatal: Datei konnte nicht geöffnet werden. Fehler %s",
+		"Fatal: Could not read %s. Error %s":                                                             "Fatal: %s konnte nicht gelesen werden. Fehler %s",
+		"Fatal: Could not read batch state. Error %s":                                                    "Fatal: Batch-Status konnte nicht gelesen werden. Fehler %s",
+		"Fatal: Could not read the scan preset. Error %s":                                                "Fatal: Das Scan-Preset konnte nicht gelesen werden. Fehler %s",
+		"Fatal: Credential check failed. Error %s":                                                       "Fatal: Prüfung der Zugangsdaten fehlgeschlagen. Fehler %s",
+		"Fatal: Error parsing LAIR_API_SERVER URL. Error %s":                                             "Fatal: Fehler beim Parsen der LAIR_API_SERVER-URL. Fehler %s",
+		"Fatal: Error setting up client: Error %s":                                                       "Fatal: Fehler beim Einrichten des Clients: Fehler %s",
+		"Fatal: Import failed. Error %s":                                                                 "Fatal: Import fehlgeschlagen. Fehler %s",
+		"Fatal: Invalid filter. Error %s":                                                                "Fatal: Ungültiger Filter. Fehler %s",
+		"Fatal: Invalid options. Error %s":                                                               "Fatal: Ungültige Optionen. Fehler %s",
+		"Fatal: Merge failed. Error %s":                                                                  "Fatal: Zusammenführen fehlgeschlagen. Fehler %s",
+		"Fatal: Missing LAIR_API_SERVER environment variable":                                            "Fatal: Umgebungsvariable LAIR_API_SERVER fehlt",
+		"Fatal: Missing required argument <filename>, or pipe bbot output to drone-bbot":                 "Fatal: Erforderliches Argument <filename> fehlt, oder leiten Sie die bbot-Ausgabe an drone-bbot weiter",
+		"Fatal: Missing required argument <id>":                                                          "Fatal: Erforderliches Argument <id> fehlt",
+		"Fatal: Missing required arguments <id> and <filename>":                                          "Fatal: Erforderliche Argumente <id> und <filename> fehlen",
+		"Fatal: Missing required arguments <old-file> and <new-file>":                                    "Fatal: Erforderliche Argumente <old-file> und <new-file> fehlen",
+		"Fatal: Missing required arguments <src-id> and <dst-id>":                                        "Fatal: Erforderliche Argumente <src-id> und <dst-id> fehlen",
+		"Fatal: Missing username and/or password":                                                        "Fatal: Benutzername und/oder Passwort fehlt",
+		"Fatal: Server stopped. Error %s":                                                                "Fatal: Server angehalten. Fehler %s",
+		"Fatal: Unable to acquire lock. Error %s":                                                        "Fatal: Sperre konnte nicht erlangt werden. Fehler %s",
+		"Fatal: Unable to list expired tags. Error %s":                                                   "Fatal: Abgelaufene Tags konnten nicht aufgelistet werden. Fehler %s",
+		"Fatal: serve requires -projects, the Lair project ids it may import into":                       "Fatal: serve erfordert -projects, die IDs der Lair-Projekte, in die importiert werden darf",
+		"Fatal: serve requires the %s environment variable to be set":                                    "Fatal: serve erfordert, dass die Umgebungsvariable %s gesetzt ist",
+		"Fatal: stdin and named pipes can not be read together with other files":                         "Fatal: stdin und benannte Pipes können nicht zusammen mit anderen Dateien gelesen werden",
+		"Import payload is %d bytes, sending it in %d parts":                                             "Die Importdaten sind %d Bytes groß und werden in %d Teilen gesendet",
+		"Imported into project %s, %d hosts created, %d hosts updated":                                   "In Projekt %s importiert, %d Hosts erstellt, %d Hosts aktualisiert",
+		"Listening for imports on %s":                                                                    "Warte auf Importe an %s",
+		"Looking up %d hosts that do not exist in lair in %s":                                            "%d Hosts, die nicht in lair existieren, werden in %s abgefragt",
+		"Looking up %d new hostnames in %s":                                                              "%d neue Hostnamen werden in %s abgefragt",
+		"Merging the events of %d files into one import":                                                 "Führe die Ereignisse von %d Dateien zu einem Import zusammen",
+		"No changes detected.":                                                                           "Keine Änderungen erkannt.",
+		"No expired tags.":                                                                               "Keine abgelaufenen Tags.",
+		"No new hosts were imported.":                                                                    "Es wurden keine neuen Hosts importiert.",
+		"Partial: -max-duration reached, re-run with the same file to continue from %s":                  "Teilweise: -max-duration erreicht, mit derselben Datei erneut ausführen, um bei %s fortzufahren",
+		"Probing %d hosts that do not exist in lair":                                                     "Prüfe %d Hosts, die nicht in lair existieren",
+		"QA: %d of %d sampled hostnames did not resolve to their host (%.0f%%), %d did not resolve":      "QA: %d von %d Hostnamen der Stichprobe wurden nicht zu ihrem Host aufgelöst (%.0f%%), %d wurden nicht aufgelöst",
+		"Read the input in %s, the import took %s":                                                       "Eingabe in %s gelesen, der Import dauerte %s",
+		"Reading %s from %s":                                                                             "Lese %s aus %s",
+		"Reading %s from stdin":                                                                          "Lese %s von stdin",
+		"Recently changed CNAME records that may allow a takeover: %s":                                   "Kürzlich geänderte CNAME-Einträge, die eine Übernahme ermöglichen könnten: %s",
+		"Recorded email addresses for %d domains":                                                        "E-Mail-Adressen für %d Domains erfasst",
+		"Refused import into project %s: %s":                                                             "Import in Projekt %s abgelehnt: %s",
+		"Refusing to import: %s. Re-run with -force to import anyway.":                                   "Import abgelehnt: %s. Mit -force erneut ausführen, um trotzdem zu importieren.",
+		"Resuming from checkpoint, skipping the first %d lines":                                          "Fortsetzung ab dem Checkpoint, die ersten %d Zeilen werden übersprungen",
+		"Resuming import, sending %d of %d parts from %s":                                                "Import wird fortgesetzt, %d von %d Teilen werden aus %s gesendet",
+		"Skipped %d DNS names that only resolve to IPv6, see -ipv6-policy: %s":                           "%d DNS-Namen übersprungen, die nur zu IPv6 aufgelöst werden, siehe -ipv6-policy: %s",
+		"Skipped %d issues already in the project with -additive-only":                                   "%d bereits im Projekt vorhandene Schwachstellen mit -additive-only übersprungen",
+		"Skipped %d new hosts with cloud provider IPs: %s":                                               "%d neue Hosts mit IPs von Cloud-Anbietern übersprungen: %s",
+		"Skipped -track-seen, this run did not read every DNS_NAME event of the input":                   "-track-seen übersprungen, dieser Lauf hat nicht alle DNS_NAME-Ereignisse der Eingabe gelesen",
+		"Skipped changes to %d existing hosts with -additive-only":                                       "Änderungen an %d vorhandenen Hosts mit -additive-only übersprungen",
+		"Skipped hostnames outside of the scope, use -allow-foreign-domains to import them: %s":          "Hostnamen außerhalb des Scopes übersprungen, verwenden Sie -allow-foreign-domains, um sie zu importieren: %s",
+		"Skipped hosts for %d domains that exceeded -max-hosts-per-domain":                               "Hosts für %d Domains übersprungen, die -max-hosts-per-domain überschritten haben",
+		"Skipped open ports on %d hosts that do not exist in lair":                                       "Offene Ports auf %d Hosts übersprungen, die nicht in lair existieren",
+		"Skipped probing %d hosts that do not exist in lair and are outside of the scope":                "%d Hosts, die nicht in lair existieren und außerhalb des Scopes liegen, wurden nicht geprüft",
+		"Success: %d hosts created, %d hosts updated":                                                    "Erfolg: %d Hosts erstellt, %d Hosts aktualisiert",
+		"Success: Credentials can export and import project %s":                                          "Erfolg: Die Zugangsdaten können Projekt %s exportieren und importieren",
+		"Success: Operation completed successfully":                                                      "Erfolg: Vorgang erfolgreich abgeschlossen",
+		"Success: attached hostnames to %d hosts, %d hosts still do not exist in lair":                   "Erfolg: Hostnamen zu %d Hosts hinzugefügt, %d Hosts existieren noch nicht in lair",
+		"Success: imported the delta, %d hosts created, %d hosts updated":                                "Erfolg: Differenz importiert, %d Hosts erstellt, %d Hosts aktualisiert",
+		"The following hosts are tagged deleted, removed or hidden and were not changed:":                "Die folgenden Hosts haben das Tag deleted, removed oder hidden und wurden nicht geändert:",
+		"The following hosts are tagged locked or manual and were not changed:":                          "Die folgenden Hosts haben das Tag locked oder manual und wurden nicht geändert:",
+		"The following hosts had DNS names but could not be imported because they do not exist in lair:": "Die folgenden Hosts hatten DNS-Namen, konnten aber nicht importiert werden, da sie nicht in lair existieren:",
+		"The following tags have expired, Lair's import can not remove tags so remove them in Lair:":     "Die folgenden Tags sind abgelaufen, der Import von Lair kann keine Tags entfernen, entfernen Sie sie in Lair:",
+		"The import finished %s after bbot's newest event":                                               "Der Import endete %s nach dem neuesten Ereignis von bbot",
+		"Uploaded %d screenshots to Lair":                                                                "%d Screenshots in Lair hochgeladen",
+		"Waiting for a writer on %s":                                                                     "Warte auf einen Schreiber an %s",
+		"Warning: Unable to write evidence to %s. Error %s":                                              "Warnung: Beweise konnten nicht nach %s geschrieben werden. Fehler %s",
+		"Warning: an item of %d bytes is larger than -max-payload-mb and is sent on its own":             "Warnung: Ein Element mit %d Bytes ist größer als -max-payload-mb und wird einzeln gesendet",
+		"Warning: no -scope or bbot targets, -force-hosts imports hostnames of any domain":               "Warnung: kein -scope und keine bbot-Ziele, -force-hosts importiert Hostnamen jeder Domain",
+		"Warning: the import no longer matches the parts recorded in %s, sending every part":             "Warnung: Der Import stimmt nicht mehr mit den in %s aufgezeichneten Teilen überein, alle Teile werden gesendet",
+		"Wrote %d URLs to %s and %s":                                                                     "%d URLs in %s und %s geschrieben",
+		"Wrote %d issues below -min-severity to %s":                                                      "%d Schwachstellen unter -min-severity in %s geschrieben",
+		"Wrote error report to %s":                                                                       "Fehlerbericht in %s geschrieben",
+	},
+}
+
+// messages holds the translations of the language selected with -lang.
+var messages = languages["en"]
+
+// setLanguage selects the language of logged messages.
+func setLanguage(lang string) error {
+	translations, ok := languages[strings.ToLower(lang)]
+	if !ok {
+		codes := []string{}
+		for code := range languages {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		return fmt.Errorf("invalid -lang %q, expected one of %s", lang, strings.Join(codes, ", "))
+	}
+	messages = translations
+	return nil
+}
+
+// tr returns the translation of an English message in the language selected
+// with -lang, or the message itself when it has no translation.
+func tr(message string) string {
+	if translated, ok := messages[message]; ok {
+		return translated
+	}
+	return message
+}
+
+// logf logs a message in the language selected with -lang, colored by its
+// severity when output is colorized.
+func logf(format string, v ...interface{}) {
+	log.Print(paint(messageColor(format), fmt.Sprintf(tr(format), v...)))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// handlerStats counts the events of one type. Processed events were passed to
+// their handler, matched events changed or created a host in the project and
+// skipped events did not, such as DNS names of hosts that do not exist in
+// Lair. Only DNS_NAME, IP_ADDRESS and OPEN_TCP_PORT events are counted as
+// matched or skipped, OPEN_TCP_PORT once per port and host.
+type handlerStats struct {
+	Processed int `json:"processed"`
+	Matched   int `json:"matched"`
+	Skipped   int `json:"skipped"`
+}
+
+// metrics break an import down by event handler, for finding out why an
+// expected asset did not appear in Lair. ReadSeconds is the time spent reading
+// the input, ImportSeconds the time from the end of the input until the
+// import into Lair finished and LagSeconds how long after the timestamp of
+// bbot's newest event that was, which shows how far behind a scan the
+// importer is with named pipes and serve.
+type metrics struct {
+	Handlers      map[string]*handlerStats `json:"handlers"`
+	Excluded      int                      `json:"excluded"`
+	PortsCreated  int                      `json:"portsCreated"`
+	IssuesCreated int                      `json:"issuesCreated"`
+	IssuesDeduped int                      `json:"issuesDeduped"`
+	ReadSeconds   float64                  `json:"readSeconds"`
+	ImportSeconds float64                  `json:"importSeconds"`
+	LagSeconds    float64                  `json:"lagSeconds,omitempty"`
+	newest        float64
+}
+
+// newMetrics returns empty metrics.
+func newMetrics() *metrics {
+	return &metrics{Handlers: make(map[string]*handlerStats)}
+}
+
+// handler returns the counts of events of eventType.
+func (m *metrics) handler(eventType string) *handlerStats {
+	if m.Handlers[eventType] == nil {
+		m.Handlers[eventType] = &handlerStats{}
+	}
+	return m.Handlers[eventType]
+}
+
+// recordTimestamp keeps the timestamp of the newest event for LagSeconds.
+func (m *metrics) recordTimestamp(entry map[string]interface{}) {
+	if ts, ok := entry["timestamp"].(float64); ok && ts > m.newest {
+		m.newest = ts
+	}
+}
+
+// finish records the time the import took after the input was read at
+// readDone, and the lag behind the newest event.
+func (m *metrics) finish(readDone time.Time) {
+	now := time.Now()
+	m.ImportSeconds = now.Sub(readDone).Seconds()
+	if m.newest > 0 {
+		m.LagSeconds = float64(now.UnixNano())/1e9 - m.newest
+	}
+}
+
+// eventCounts lists the number of events of each imported type as
+// "DNS_NAME 12, OPEN_TCP_PORT 3", sorted by type.
+func (m *metrics) eventCounts() string {
+	counts := []string{}
+	for t, h := range m.Handlers {
+		if h.Processed > 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", t, h.Processed))
+		}
+	}
+	sort.Strings(counts)
+	return strings.Join(counts, ", ")
+}
+
+// logStats logs the event counts and latencies of an import, after each
+// import of a named pipe or serve.
+func (m *metrics) logStats() {
+	if counts := m.eventCounts(); counts != "" {
+		logf("Events by type in this import: %s", counts)
+	}
+	logf("Read the input in %s, the import took %s", seconds(m.ReadSeconds), seconds(m.ImportSeconds))
+	if m.LagSeconds > 0 {
+		logf("The import finished %s after bbot's newest event", seconds(m.LagSeconds))
+	}
+}
+
+// seconds formats a number of seconds as a duration rounded to milliseconds.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
+
+// table formats the metrics as a table with one row per event type, sorted by
+// type, followed by the port and issue counts.
+func (m *metrics) table() []string {
+	types := []string{}
+	for t := range m.Handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT TYPE\tPROCESSED\tMATCHED\tSKIPPED")
+	for _, t := range types {
+		h := m.Handlers[t]
+		if h.Matched+h.Skipped > 0 {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", t, h.Processed, h.Matched, h.Skipped)
+		} else {
+			fmt.Fprintf(w, "%s\t%d\t-\t-\n", t, h.Processed)
+		}
+	}
+	w.Flush()
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if m.Excluded > 0 {
+		lines = append(lines, fmt.Sprintf("%d events excluded by -only or -modules", m.Excluded))
+	}
+	return append(lines, fmt.Sprintf("%d ports created, %d issues created, %d duplicate issues merged", m.PortsCreated, m.IssuesCreated, m.IssuesDeduped))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventCounts(t *testing.T) {
+	m := newMetrics()
+	if got := m.eventCounts(); got != "" {
+		t.Errorf("eventCounts() of no events = %q, want \"\"", got)
+	}
+	m.handler("OPEN_TCP_PORT").Processed = 3
+	m.handler("DNS_NAME").Processed = 12
+	m.handler("FINDING")
+	if got, want := m.eventCounts(), "DNS_NAME 12, OPEN_TCP_PORT 3"; got != want {
+		t.Errorf("eventCounts() = %q, want %q", got, want)
+	}
+}
+
+func TestMetricsLag(t *testing.T) {
+	m := newMetrics()
+	newest := float64(time.Now().Add(-time.Minute).Unix())
+	for _, entry := range []map[string]interface{}{
+		{"type": "DNS_NAME", "timestamp": newest - 30},
+		{"type": "DNS_NAME", "timestamp": newest},
+		{"type": "DNS_NAME", "timestamp": "2024-01-01T00:00:00"},
+		{"type": "DNS_NAME"},
+	} {
+		m.recordTimestamp(entry)
+	}
+	m.finish(time.Now().Add(-time.Second))
+	if m.ImportSeconds < 1 || m.ImportSeconds > 10 {
+		t.Errorf("ImportSeconds = %v, want about 1", m.ImportSeconds)
+	}
+	if m.LagSeconds < 60 || m.LagSeconds > 70 {
+		t.Errorf("LagSeconds = %v, want about 60", m.LagSeconds)
+	}
+
+	m = newMetrics()
+	m.finish(time.Now())
+	if m.LagSeconds != 0 {
+		t.Errorf("LagSeconds without timestamps = %v, want 0", m.LagSeconds)
+	}
+}
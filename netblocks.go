@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// recordNetblock remembers a netblock, filling in the empty fields of one with
+// the same CIDR already recorded during this import.
+func (im *importer) recordNetblock(netblock lair.Netblock) {
+	_, ipnet, err := net.ParseCIDR(netblock.CIDR)
+	if err != nil {
+		return
+	}
+	netblock.CIDR = ipnet.String()
+	existing, found := im.netblocks[netblock.CIDR]
+	if !found {
+		im.netblocks[netblock.CIDR] = &netblock
+		return
+	}
+	for _, field := range []struct{ dst, src *string }{
+		{&existing.ASN, &netblock.ASN},
+		{&existing.ASNCIDR, &netblock.ASNCIDR},
+		{&existing.ASNCountryCode, &netblock.ASNCountryCode},
+		{&existing.Name, &netblock.Name},
+		{&existing.Description, &netblock.Description},
+	} {
+		if *field.dst == "" {
+			*field.dst = *field.src
+		}
+	}
+}
+
+// handleASN records a netblock for each subnet of an ASN event from bbot's asn
+// module, with the AS number, name, description and country.
+func (im *importer) handleASN(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	if data == nil {
+		return
+	}
+	asn := ""
+	if number, found := data["asn"]; found {
+		asn = sanitizeText(fmt.Sprint(number))
+	}
+	name, _ := data["name"].(string)
+	description, _ := data["description"].(string)
+	country, _ := data["country"].(string)
+	subnets, _ := data["subnets"].([]interface{})
+	if subnet, ok := data["subnet"].(string); ok {
+		subnets = append(subnets, subnet)
+	}
+	for _, subnet := range subnets {
+		cidr, _ := subnet.(string)
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		im.recordNetblock(lair.Netblock{
+			CIDR:           ipnet.String(),
+			ASN:            asn,
+			ASNCIDR:        ipnet.String(),
+			ASNCountryCode: sanitizeText(country),
+			Name:           sanitizeText(name),
+			Description:    sanitizeText(description),
+		})
+	}
+}
+
+// handleIPRange records the CIDR of an IP_RANGE event as a netblock.
+func (im *importer) handleIPRange(entry map[string]interface{}) {
+	cidr, _ := entry["data"].(string)
+	im.recordNetblock(lair.Netblock{CIDR: cidr})
+}
+
+// applyNetblocks adds the recorded netblocks to the project. Lair only fills
+// in the empty fields of a netblock it already has.
+func (im *importer) applyNetblocks() {
+	cidrs := []string{}
+	for cidr := range im.netblocks {
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+	for _, cidr := range cidrs {
+		im.project.Netblocks = append(im.project.Netblocks, *im.netblocks[cidr])
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// lastModifiedBy attributes the hosts, notes and issues created or changed by
+// an import. It is the tool name, followed by the -operator in parentheses
+// when one is given.
+var lastModifiedBy = tool
+
+// stringList is a flag.Value that collects every occurrence of a repeatable
+// flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// options controls how bbot events are imported into a project. They are
+// shared by the default import command and the serve subcommand.
+type options struct {
+	forceHosts        bool
+	tags              string
+	probeUnmatched    bool
+	probePorts        string
+	probeRate         int
+	probeTimeout      time.Duration
+	maxHostsPerDomain int
+	tui               bool
+	airgap            bool
+	detectChanges     bool
+	force             bool
+	maxProjectHosts   int
+	flagWhen          string
+
+	importAuthInterfaces bool
+	exportBurp           string
+	headerIssues         bool
+	trackSeen            bool
+	retireMissing        int
+	emailTo              string
+	emailFrom            string
+	smtpServer           string
+	ticketSystem         string
+	ticketURL            string
+	ticketQueue          string
+	ticketTemplate       string
+	minSeverity          string
+	skippedIssuesFile    string
+	operator             string
+	tagNamespace         string
+	slugTags             bool
+	migrateTags          bool
+	cpeNotes             bool
+	only                 string
+	modules              string
+	maxDuration          time.Duration
+	checkpoint           string
+	batchState           string
+	format               string
+	hostDisplay          string
+	scanDir              string
+	lowConfidence        int
+	ipv6Policy           string
+	txtSecrets           bool
+	txtRulesFile         string
+	serviceTags          bool
+	commandLabel         string
+	tagList              stringList
+	tagsFile             string
+	maxPayloadMB         int
+	findings             string
+	provenance           bool
+	emailNotes           bool
+	additiveOnly         bool
+	certIssues           bool
+	certExpiryDays       int
+	qaSample             int
+	tagTTL               time.Duration
+	scopeList            string
+	allowForeignDomains  bool
+	vhostNotes           bool
+	notFoundNote         bool
+	osintNotes           bool
+	skipCloudIPs         bool
+	lang                 string
+	screenshots          string
+	includeUnresolved    string
+	enrich               string
+	shodanKey            string
+	passiveDNSSource     string
+	passiveDNSKey        string
+	speculatedPorts      bool
+	maxEvidenceBytes     int
+	evidenceDir          string
+
+	hostTags        []string
+	rawTags         []string
+	ports           []int
+	flagRule        flagRule
+	emailRecipients []string
+	scope           []string
+	ticketer        *ticketer
+	enricher        *enricher
+	passiveDNS      *passiveDNS
+	absent          *absentCache
+	scanPreset      *scanMeta
+	minSeverityRank int
+	onlyTypes       map[string]bool
+	onlyModules     map[string]bool
+	txtRules        []txtRule
+}
+
+// registerFlags defines the import flags on fs and returns the options they
+// populate. prepare must be called once fs has been parsed.
+func registerFlags(fs *flag.FlagSet) *options {
+	opts := &options{}
+	fs.BoolVar(&opts.forceHosts, "force-hosts", false, "")
+	fs.StringVar(&opts.tags, "tags", "", "")
+	fs.BoolVar(&opts.probeUnmatched, "probe-unmatched", false, "")
+	fs.StringVar(&opts.probePorts, "probe-ports", defaultProbePorts, "")
+	fs.IntVar(&opts.probeRate, "probe-rate", 100, "")
+	fs.DurationVar(&opts.probeTimeout, "probe-timeout", 2*time.Second, "")
+	fs.IntVar(&opts.maxHostsPerDomain, "max-hosts-per-domain", 0, "")
+	fs.BoolVar(&opts.tui, "tui", false, "")
+	fs.BoolVar(&opts.airgap, "airgap", false, "")
+	fs.BoolVar(&opts.detectChanges, "detect-changes", false, "")
+	fs.BoolVar(&opts.force, "force", false, "")
+	fs.IntVar(&opts.maxProjectHosts, "max-project-hosts", 0, "")
+	fs.StringVar(&opts.flagWhen, "flag-when", "", "")
+	fs.BoolVar(&opts.importAuthInterfaces, "import-auth-interfaces", false, "")
+	fs.StringVar(&opts.exportBurp, "export-burp", "", "")
+	fs.BoolVar(&opts.headerIssues, "header-issues", false, "")
+	fs.BoolVar(&opts.trackSeen, "track-seen", false, "")
+	fs.IntVar(&opts.retireMissing, "retire-missing", 0, "")
+	fs.StringVar(&opts.emailTo, "email-to", "", "")
+	fs.StringVar(&opts.emailFrom, "email-from", "drone-bbot@localhost", "")
+	fs.StringVar(&opts.smtpServer, "smtp", "localhost:25", "")
+	fs.StringVar(&opts.ticketSystem, "ticket-system", "", "")
+	fs.StringVar(&opts.ticketURL, "ticket-url", "", "")
+	fs.StringVar(&opts.ticketQueue, "ticket-queue", "", "")
+	fs.StringVar(&opts.ticketTemplate, "ticket-template", "", "")
+	fs.StringVar(&opts.minSeverity, "min-severity", "info", "")
+	fs.StringVar(&opts.skippedIssuesFile, "skipped-issues-file", "", "")
+	fs.StringVar(&opts.operator, "operator", "", "")
+	fs.StringVar(&opts.tagNamespace, "tag-namespace", "bbot:", "")
+	fs.BoolVar(&opts.slugTags, "slug-tags", false, "")
+	fs.BoolVar(&opts.migrateTags, "migrate-tags", false, "")
+	fs.BoolVar(&opts.cpeNotes, "cpe-notes", false, "")
+	fs.StringVar(&opts.only, "only", "", "")
+	fs.StringVar(&opts.modules, "modules", "", "")
+	fs.DurationVar(&opts.maxDuration, "max-duration", 0, "")
+	fs.StringVar(&opts.checkpoint, "checkpoint", "", "")
+	fs.StringVar(&opts.batchState, "batch-state", "", "")
+	fs.StringVar(&opts.format, "format", "", "")
+	fs.StringVar(&opts.hostDisplay, "host-display", "", "")
+	fs.StringVar(&opts.scanDir, "scan-dir", "", "")
+	fs.IntVar(&opts.lowConfidence, "low-confidence", 0, "")
+	fs.StringVar(&opts.ipv6Policy, "ipv6-policy", "skip", "")
+	fs.BoolVar(&opts.txtSecrets, "txt-secrets", false, "")
+	fs.StringVar(&opts.txtRulesFile, "txt-rules", "", "")
+	fs.BoolVar(&opts.serviceTags, "service-tags", false, "")
+	fs.StringVar(&opts.commandLabel, "command-label", "", "")
+	fs.Var(&opts.tagList, "tag", "")
+	fs.StringVar(&opts.tagsFile, "tags-file", "", "")
+	fs.IntVar(&opts.maxPayloadMB, "max-payload-mb", 8, "")
+	fs.StringVar(&opts.findings, "findings", "", "")
+	fs.BoolVar(&opts.provenance, "provenance", false, "")
+	fs.BoolVar(&opts.emailNotes, "email-notes", false, "")
+	fs.BoolVar(&opts.additiveOnly, "additive-only", false, "")
+	fs.BoolVar(&opts.certIssues, "cert-issues", false, "")
+	fs.IntVar(&opts.certExpiryDays, "cert-expiry-days", 30, "")
+	fs.IntVar(&opts.qaSample, "qa-sample", 0, "")
+	fs.DurationVar(&opts.tagTTL, "tag-ttl", 0, "")
+	fs.StringVar(&opts.scopeList, "scope", "", "")
+	fs.BoolVar(&opts.allowForeignDomains, "allow-foreign-domains", false, "")
+	fs.BoolVar(&opts.vhostNotes, "vhost-notes", false, "")
+	fs.BoolVar(&opts.notFoundNote, "not-found-note", false, "")
+	fs.BoolVar(&opts.osintNotes, "osint-notes", false, "")
+	fs.BoolVar(&opts.skipCloudIPs, "skip-cloud-ips", false, "")
+	fs.StringVar(&opts.lang, "lang", "en", "")
+	fs.StringVar(&opts.screenshots, "screenshots", "", "")
+	fs.StringVar(&opts.includeUnresolved, "include-unresolved", "", "")
+	fs.StringVar(&opts.enrich, "enrich", "", "")
+	fs.StringVar(&opts.shodanKey, "shodan-key", "", "")
+	fs.StringVar(&opts.passiveDNSSource, "passive-dns", "", "")
+	fs.StringVar(&opts.passiveDNSKey, "passive-dns-key", "", "")
+	fs.BoolVar(&opts.speculatedPorts, "speculated-ports", false, "")
+	fs.IntVar(&opts.maxEvidenceBytes, "max-evidence-bytes", 0, "")
+	fs.StringVar(&opts.evidenceDir, "evidence-dir", "", "")
+	return opts
+}
+
+// prepare validates the parsed flags and derives the values used during import.
+func (o *options) prepare() error {
+	if err := setLanguage(o.lang); err != nil {
+		return err
+	}
+	var err error
+	o.ports, err = parsePorts(o.probePorts)
+	if err != nil {
+		return fmt.Errorf("invalid -probe-ports: %s", err.Error())
+	}
+	if o.probeRate < 1 || o.probeRate > maxProbeRate {
+		return fmt.Errorf("-probe-rate must be between 1 and %d", maxProbeRate)
+	}
+	if o.airgap && o.probeUnmatched {
+		return errors.New("-probe-unmatched connects to hosts outside of Lair and can not be used with -airgap")
+	}
+	if o.flagWhen != "" {
+		o.flagRule, err = parseFlagRule(o.flagWhen)
+		if err != nil {
+			return fmt.Errorf("invalid -flag-when expression: %s", err.Error())
+		}
+	}
+	if o.certExpiryDays < 0 {
+		return errors.New("-cert-expiry-days can not be negative")
+	}
+	if o.maxEvidenceBytes < 0 {
+		return errors.New("-max-evidence-bytes can not be negative")
+	}
+	if o.evidenceDir != "" {
+		if o.maxEvidenceBytes == 0 {
+			return errors.New("-evidence-dir requires -max-evidence-bytes")
+		}
+		if err := os.MkdirAll(o.evidenceDir, 0700); err != nil {
+			return fmt.Errorf("invalid -evidence-dir: %s", err.Error())
+		}
+	}
+	if o.additiveOnly && (o.migrateTags || o.retireMissing > 0) {
+		return errors.New("-migrate-tags and -retire-missing change existing hosts and can not be used with -additive-only")
+	}
+	if o.airgap && o.qaSample > 0 {
+		return errors.New("-qa-sample resolves hostnames with DNS and can not be used with -airgap")
+	}
+	if o.airgap && o.emailTo != "" {
+		return errors.New("-email-to connects to an SMTP server and can not be used with -airgap")
+	}
+	if o.ticketSystem != "" {
+		if o.airgap {
+			return errors.New("-ticket-system connects to a ticketing service and can not be used with -airgap")
+		}
+		o.ticketer, err = newTicketer(o.ticketSystem, o.ticketURL, o.ticketQueue, o.ticketTemplate)
+		if err != nil {
+			return fmt.Errorf("invalid ticket options: %s", err.Error())
+		}
+	}
+	if o.enrich != "" {
+		if o.airgap {
+			return errors.New("-enrich queries Shodan or Censys and can not be used with -airgap")
+		}
+		o.enricher, err = newEnricher(o.enrich, o.shodanKey)
+		if err != nil {
+			return fmt.Errorf("invalid -enrich: %s", err.Error())
+		}
+	}
+	if o.passiveDNSSource != "" {
+		if o.airgap {
+			return errors.New("-passive-dns queries VirusTotal or SecurityTrails and can not be used with -airgap")
+		}
+		o.passiveDNS, err = newPassiveDNS(o.passiveDNSSource, o.passiveDNSKey)
+		if err != nil {
+			return fmt.Errorf("invalid -passive-dns: %s", err.Error())
+		}
+	}
+	var ok bool
+	if o.minSeverityRank, ok = severityRank(o.minSeverity); !ok {
+		return fmt.Errorf("invalid -min-severity %q, expected one of %s", o.minSeverity, strings.Join(severities, ", "))
+	}
+	validMode := false
+	for _, mode := range findingModes {
+		validMode = validMode || o.findings == mode
+	}
+	if !validMode {
+		return fmt.Errorf("invalid -findings %q, expected issue or note", o.findings)
+	}
+	validMode = false
+	for _, mode := range screenshotModes {
+		validMode = validMode || o.screenshots == mode
+	}
+	if !validMode {
+		return fmt.Errorf("invalid -screenshots %q, expected note or upload", o.screenshots)
+	}
+	validMode = false
+	for _, mode := range unresolvedModes {
+		validMode = validMode || o.includeUnresolved == mode
+	}
+	if !validMode {
+		return fmt.Errorf("invalid -include-unresolved %q, expected note or host", o.includeUnresolved)
+	}
+	validMode = false
+	for _, mode := range inputFormats {
+		validMode = validMode || o.format == mode
+	}
+	if !validMode {
+		return fmt.Errorf("invalid -format %q, expected ndjson, json, csv or sqlite", o.format)
+	}
+	validMode = false
+	for _, mode := range hostDisplays {
+		validMode = validMode || o.hostDisplay == mode
+	}
+	if !validMode {
+		return fmt.Errorf("invalid -host-display %q, expected ip or hostname", o.hostDisplay)
+	}
+	validPolicy := false
+	for _, policy := range ipv6Policies {
+		validPolicy = validPolicy || o.ipv6Policy == policy
+	}
+	if !validPolicy {
+		return fmt.Errorf("invalid -ipv6-policy %q, expected one of %s", o.ipv6Policy, strings.Join(ipv6Policies, ", "))
+	}
+	if o.only != "" {
+		o.onlyTypes = make(map[string]bool)
+		for _, eventType := range strings.Split(o.only, ",") {
+			eventType = strings.ToUpper(strings.TrimSpace(eventType))
+			if !isImportedEventType(eventType) {
+				return fmt.Errorf("invalid -only event type %q, expected one of %s", eventType, strings.Join(importedEventTypes, ", "))
+			}
+			o.onlyTypes[eventType] = true
+		}
+	}
+	if o.modules != "" {
+		o.onlyModules = make(map[string]bool)
+		for _, module := range strings.Split(o.modules, ",") {
+			if module = strings.ToLower(strings.TrimSpace(module)); module != "" {
+				o.onlyModules[module] = true
+			}
+		}
+		if len(o.onlyModules) == 0 {
+			return fmt.Errorf("invalid -modules %q, expected a comma separated list of bbot modules", o.modules)
+		}
+	}
+	if o.txtSecrets || o.txtRulesFile != "" {
+		o.txtRules = append([]txtRule{}, defaultTXTRules...)
+	}
+	if o.txtRulesFile != "" {
+		custom, err := loadTXTRules(o.txtRulesFile)
+		if err != nil {
+			return fmt.Errorf("invalid -txt-rules: %s", err.Error())
+		}
+		o.txtRules = append(o.txtRules, custom...)
+	}
+	o.commandLabel = sanitizeText(o.commandLabel)
+	if o.operator != "" {
+		lastModifiedBy = fmt.Sprintf("%s(%s)", tool, sanitizeText(o.operator))
+	}
+	if o.retireMissing > 0 {
+		o.trackSeen = true
+	}
+	for _, addr := range strings.Split(o.emailTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			o.emailRecipients = append(o.emailRecipients, addr)
+		}
+	}
+	o.scope = splitTags(o.scopeList)
+	tags := splitTags(o.tags)
+	for _, t := range o.tagList {
+		tags = append(tags, splitTags(t)...)
+	}
+	if o.tagsFile != "" {
+		fileTags, err := readTagsFile(o.tagsFile)
+		if err != nil {
+			return fmt.Errorf("invalid -tags-file: %s", err.Error())
+		}
+		tags = append(tags, fileTags...)
+	}
+	o.tagNamespace = sanitizeTag(o.tagNamespace, o.slugTags)
+	o.rawTags, _ = appendUnique(nil, tags...)
+	o.hostTags = []string{}
+	for _, tag := range o.rawTags {
+		if tag = o.namespaceTag(tag); tag != "" && tag != o.tagNamespace {
+			o.hostTags, _ = appendUnique(o.hostTags, tag)
+		}
+	}
+	// hosts share hostTags, cap it so appending to one host's tags copies them
+	o.hostTags = o.hostTags[:len(o.hostTags):len(o.hostTags)]
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// osintNoteTitle is the title of the project note listing the code
+// repositories and social media profiles bbot found.
+const osintNoteTitle = "drone-bbot: code repositories and social profiles"
+
+// handleOSINT records the URL of a CODE_REPOSITORY or SOCIAL event with
+// -osint-notes, described by its platform and the module that found it.
+func (im *importer) handleOSINT(entry map[string]interface{}) {
+	if !im.opts.osintNotes {
+		return
+	}
+	data, _ := entry["data"].(map[string]interface{})
+	rawURL, _ := data["url"].(string)
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || strings.ContainsAny(rawURL, " \t\n") {
+		return
+	}
+	kind := "code repository"
+	if entry["type"] == "SOCIAL" {
+		kind = "social profile"
+	}
+	details := []string{kind}
+	if platform, _ := data["platform"].(string); platform != "" {
+		details = append(details, platform)
+	}
+	if name, _ := data["profile_name"].(string); name != "" {
+		details = append(details, name)
+	}
+	if module, _ := entry["module"].(string); module != "" {
+		details = append(details, "found by "+module)
+	}
+	if _, found := im.osint[rawURL]; !found {
+		im.osint[rawURL] = fmt.Sprintf("%s (%s)", rawURL, strings.Join(details, ", "))
+	}
+}
+
+// osintNotes returns the project note listing the recorded repositories and
+// profiles that are not in the project yet.
+func (im *importer) osintNotes(existing []lair.Note) []lair.Note {
+	lines := []string{}
+	for _, line := range im.osint {
+		lines = append(lines, line)
+	}
+	if note, ok := listNote(osintNoteTitle, lines, existing); ok {
+		return []lair.Note{note}
+	}
+	return nil
+}
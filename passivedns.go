@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// passiveDNSSources are the values accepted by -passive-dns.
+var passiveDNSSources = []string{"virustotal", "securitytrails"}
+
+// passiveDNSNoteTitle is the title of the host note listing when each new
+// hostname of the host was first seen by the passive DNS source.
+const passiveDNSNoteTitle = "drone-bbot: passive DNS history"
+
+// passiveDNSRecent is how recently a hostname must have started resolving to
+// its host for the record to be reported as recently changed.
+const passiveDNSRecent = 30 * 24 * time.Hour
+
+// passiveDNSInterval is the time between lookups, within the rate limits of
+// the free VirusTotal and SecurityTrails APIs.
+var passiveDNSInterval = map[string]time.Duration{
+	"virustotal":     15 * time.Second,
+	"securitytrails": time.Second,
+}
+
+// dnsResolution is an address a hostname resolved to, and when the passive
+// DNS source first saw it.
+type dnsResolution struct {
+	ip        string
+	firstSeen time.Time
+}
+
+// passiveDNS looks up the resolution history of hostnames in VirusTotal or
+// SecurityTrails. The key is read from -passive-dns-key, VT_API_KEY or
+// SECURITYTRAILS_API_KEY.
+type passiveDNS struct {
+	source  string
+	baseURL string
+	key     string
+	client  *http.Client
+}
+
+// newPassiveDNS validates the passive DNS options.
+func newPassiveDNS(source, key string) (*passiveDNS, error) {
+	p := &passiveDNS{source: source, key: key, client: &http.Client{Timeout: 30 * time.Second}}
+	var env string
+	switch source {
+	case "virustotal":
+		p.baseURL, env = "https://www.virustotal.com", "VT_API_KEY"
+	case "securitytrails":
+		p.baseURL, env = "https://api.securitytrails.com", "SECURITYTRAILS_API_KEY"
+	default:
+		return nil, fmt.Errorf("unsupported source %q, expected %s", source, strings.Join(passiveDNSSources, " or "))
+	}
+	if p.key == "" {
+		p.key = os.Getenv(env)
+	}
+	if p.key == "" {
+		return nil, fmt.Errorf("-passive-dns-key or %s is required", env)
+	}
+	return p, nil
+}
+
+// history returns the addresses the source saw name resolve to, oldest first.
+// A name the source knows nothing about has no history.
+func (p *passiveDNS) history(name string) ([]dnsResolution, error) {
+	var reqURL, header string
+	if p.source == "virustotal" {
+		reqURL, header = p.baseURL+"/api/v3/domains/"+url.PathEscape(name)+"/resolutions?limit=40", "x-apikey"
+	} else {
+		reqURL, header = p.baseURL+"/v1/history/"+url.PathEscape(name)+"/dns/a", "APIKEY"
+	}
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(header, p.key)
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, errEnrichAuth
+	default:
+		return nil, fmt.Errorf("%s responded %d", p.source, res.StatusCode)
+	}
+	var resolutions []dnsResolution
+	if p.source == "virustotal" {
+		resolutions, err = virusTotalResolutions(data)
+	} else {
+		resolutions, err = securityTrailsResolutions(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(resolutions, func(i, j int) bool { return resolutions[i].firstSeen.Before(resolutions[j].firstSeen) })
+	return resolutions, nil
+}
+
+// virusTotalResolutions returns the resolutions in a VirusTotal domain
+// resolutions response. VirusTotal reports when each resolution was last
+// observed, so the earliest observation of an address is used as its first
+// seen date.
+func virusTotalResolutions(data []byte) ([]dnsResolution, error) {
+	var res struct {
+		Data []struct {
+			Attributes struct {
+				Date      int64  `json:"date"`
+				IPAddress string `json:"ip_address"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	first := map[string]time.Time{}
+	for _, d := range res.Data {
+		ip, seen := d.Attributes.IPAddress, time.Unix(d.Attributes.Date, 0).UTC()
+		if t, ok := first[ip]; ip != "" && (!ok || seen.Before(t)) {
+			first[ip] = seen
+		}
+	}
+	resolutions := []dnsResolution{}
+	for ip, seen := range first {
+		resolutions = append(resolutions, dnsResolution{ip: ip, firstSeen: seen})
+	}
+	return resolutions, nil
+}
+
+// securityTrailsResolutions returns the resolutions in a SecurityTrails A
+// record history response.
+func securityTrailsResolutions(data []byte) ([]dnsResolution, error) {
+	var res struct {
+		Records []struct {
+			FirstSeen string `json:"first_seen"`
+			Values    []struct {
+				IP string `json:"ip"`
+			} `json:"values"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	resolutions := []dnsResolution{}
+	for _, r := range res.Records {
+		seen, err := time.Parse("2006-01-02", r.FirstSeen)
+		if err != nil {
+			continue
+		}
+		for _, v := range r.Values {
+			resolutions = append(resolutions, dnsResolution{ip: v.IP, firstSeen: seen})
+		}
+	}
+	return resolutions, nil
+}
+
+// passiveDNSLine describes the history of name resolving to ip, and reports
+// whether the record changed within passiveDNSRecent of now. A record the
+// source has not seen yet, or first saw recently after it pointed elsewhere,
+// has changed recently.
+func passiveDNSLine(name, ip string, history []dnsResolution, now time.Time) (string, bool) {
+	var first time.Time
+	previous := []string{}
+	for _, r := range history {
+		switch {
+		case r.ip == ip:
+			if first.IsZero() {
+				first = r.firstSeen
+			}
+		case first.IsZero():
+			previous, _ = appendUnique(previous, r.ip)
+		}
+	}
+	if first.IsZero() {
+		return fmt.Sprintf("%s not seen resolving to %s yet, changed recently", name, ip), true
+	}
+	line := fmt.Sprintf("%s first seen %s resolving to %s", name, first.Format("2006-01-02"), ip)
+	if len(previous) == 0 || now.Sub(first) > passiveDNSRecent {
+		return line, false
+	}
+	return line + ", changed recently from " + strings.Join(previous, ", "), true
+}
+
+// applyPassiveDNS looks up the hostnames this import adds in the -passive-dns
+// source and records when each was first seen resolving to its host in a host
+// note. Recently changed records that are a CNAME for another name are
+// reported, as a takeover of the target is easiest right after such a
+// change.
+func (im *importer) applyPassiveDNS(existing []lair.Host, now time.Time) error {
+	names := im.importedNames(im.project.Hosts, existing)
+	if len(names) == 0 {
+		return nil
+	}
+	p := im.opts.passiveDNS
+	logf("Looking up %d new hostnames in %s", len(names), p.source)
+	ticker := time.NewTicker(passiveDNSInterval[p.source])
+	defer ticker.Stop()
+	lines := map[string][]string{}
+	changed := []string{}
+	for i, n := range names {
+		if i > 0 {
+			<-ticker.C
+		}
+		history, err := p.history(n.name)
+		if err == errEnrichAuth {
+			return fmt.Errorf("%s: %s", p.source, err.Error())
+		}
+		if err != nil {
+			logf("Error: Unable to look up %s. Error %s", n.name, err.Error())
+			continue
+		}
+		line, recent := passiveDNSLine(n.name, n.ip, history, now)
+		if target := im.cnames[strings.ToLower(n.name)]; recent && target != "" {
+			line += ", CNAME to " + target + ", check for a takeover"
+			changed = append(changed, n.name)
+		}
+		im.dnsHistory[strings.ToLower(n.name)] = line
+		lines[n.ip] = append(lines[n.ip], line)
+	}
+	for ip, hostLines := range lines {
+		im.updateHost(ip, func(host *lair.Host) bool {
+			note, ok := listNote(passiveDNSNoteTitle, hostLines, host.Notes)
+			if ok {
+				host.Notes = append(host.Notes, note)
+			}
+			return ok
+		})
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		logf("Recently changed CNAME records that may allow a takeover: %s", strings.Join(changed, ", "))
+	}
+	return nil
+}
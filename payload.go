@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// mongoDocumentLimit is the largest document MongoDB stores. Lair stores each
+// host, with its services and notes, as one document.
+const mongoDocumentLimit = 16 << 20
+
+// jsonSize returns the length of v serialized as JSON.
+func jsonSize(v interface{}) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// splitProject divides project into parts whose serialized size is at most
+// max bytes, packing hosts, issues, auth interfaces, notes, netblocks and
+// people in order. Lair rejects imports without a command, so every part
+// carries the command. A host too large for MongoDB is an error, other items
+// larger than max are sent alone.
+func splitProject(project *lair.Project, max int) ([]*lair.Project, error) {
+	for _, host := range project.Hosts {
+		if size := jsonSize(host); size > mongoDocumentLimit {
+			return nil, fmt.Errorf("host %s is %d bytes, above the MongoDB document limit of %d bytes", host.IPv4, size, mongoDocumentLimit)
+		}
+	}
+	if max <= 0 || jsonSize(project) <= max {
+		return []*lair.Project{project}, nil
+	}
+
+	newPart := func() *lair.Project {
+		return &lair.Project{ID: project.ID, Tool: project.Tool, Commands: project.Commands}
+	}
+	parts := []*lair.Project{newPart()}
+	size, empty := jsonSize(parts[0]), true
+	add := func(item interface{}, place func(*lair.Project)) {
+		itemSize := jsonSize(item) + 1
+		if size+itemSize > max && !empty {
+			parts = append(parts, newPart())
+			size = jsonSize(parts[len(parts)-1])
+		}
+		if itemSize > max {
+			logf("Warning: an item of %d bytes is larger than -max-payload-mb and is sent on its own", itemSize)
+		}
+		place(parts[len(parts)-1])
+		size += itemSize
+		empty = false
+	}
+	for _, host := range project.Hosts {
+		add(host, func(p *lair.Project) { p.Hosts = append(p.Hosts, host) })
+	}
+	for _, issue := range project.Issues {
+		add(issue, func(p *lair.Project) { p.Issues = append(p.Issues, issue) })
+	}
+	for _, ai := range project.AuthInterfaces {
+		add(ai, func(p *lair.Project) { p.AuthInterfaces = append(p.AuthInterfaces, ai) })
+	}
+	for _, note := range project.Notes {
+		add(note, func(p *lair.Project) { p.Notes = append(p.Notes, note) })
+	}
+	for _, netblock := range project.Netblocks {
+		add(netblock, func(p *lair.Project) { p.Netblocks = append(p.Netblocks, netblock) })
+	}
+	for _, person := range project.People {
+		add(person, func(p *lair.Project) { p.People = append(p.People, person) })
+	}
+	return parts, nil
+}
+
+// importProject sends project to Lair, split into parts of at most maxPayload
+// bytes when maxPayload is non-zero, and fails if Lair rejects any part. With
+// a statePath, the parts Lair acknowledged are recorded there so that a failed
+// import can be resumed by importing the same input, whose digest is input,
+// again. A state that no longer matches the import is replaced, even when the
+// import now fits in one part, so that it does not block later imports.
+func importProject(c *client.C, project *lair.Project, maxPayload int, statePath, input string) error {
+	sortProject(project)
+	state, err := readBatchState(statePath)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		if state.Project != project.ID {
+			return fmt.Errorf("%s holds an import into project %s", statePath, state.Project)
+		}
+		if state.Input != input {
+			return fmt.Errorf("%s holds an import of different input, re-run with the same input or remove it", statePath)
+		}
+		if parts, ok := state.rebuild(project); ok {
+			logf("Resuming import, sending %d of %d parts from %s", state.remaining(), len(parts), statePath)
+			return sendBatches(c, state, parts, statePath)
+		}
+		logf("Warning: the import no longer matches the parts recorded in %s, sending every part", statePath)
+	}
+	parts, err := splitProject(project, maxPayload)
+	if err != nil {
+		return err
+	}
+	if len(parts) > 1 {
+		logf("Import payload is %d bytes, sending it in %d parts", jsonSize(project), len(parts))
+	} else if state == nil {
+		statePath = ""
+	}
+	return sendBatches(c, newBatchState(project.ID, input, parts), parts, statePath)
+}
+
+// sendPart sends one part of an import to Lair.
+func sendPart(c *client.C, part *lair.Project) error {
+	res, err := c.ImportProject(&client.DOptions{}, part)
+	if err != nil {
+		return err
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Lair responded %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
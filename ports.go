@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// speculateModule is the bbot module that emits OPEN_TCP_PORT events for ports
+// it guesses are open, such as 80 and 443 for every web host, without
+// connecting to them.
+const speculateModule = "speculate"
+
+// unconfirmedNoteTitle is the title of the note on services created for ports
+// the speculate module guessed.
+const unconfirmedNoteTitle = "drone-bbot: unconfirmed"
+
+// handleOpenTCPPort records the port of an OPEN_TCP_PORT event, whose data is
+// host:port, for every IP the host resolved to. Ports the speculate module
+// guessed are skipped, as they were never confirmed open, unless
+// -speculated-ports is set.
+func (im *importer) handleOpenTCPPort(entry map[string]interface{}) {
+	ports := im.openPorts
+	if module, _ := entry["module"].(string); module == speculateModule {
+		if !im.opts.speculatedPorts {
+			im.metrics.handler("OPEN_TCP_PORT").Skipped++
+			return
+		}
+		ports = im.speculatedPorts
+	}
+	data, _ := entry["data"].(string)
+	host, rawPort, err := net.SplitHostPort(data)
+	if err != nil {
+		return
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil || port < 1 || port > 65535 {
+		return
+	}
+	ips := eventIPs(entry)
+	if net.ParseIP(host) != nil {
+		ips, _ = appendUnique(ips, host)
+	}
+	for _, ip := range ips {
+		if ports[ip] == nil {
+			ports[ip] = make(map[int]bool)
+		}
+		ports[ip][port] = true
+	}
+}
+
+// applyOpenPorts adds a tcp service for each open port to its host. Hosts that
+// are not in the project are created with -force-hosts, or as low-confidence
+// hosts with -low-confidence, and skipped otherwise.
+func (im *importer) applyOpenPorts() {
+	ips := []string{}
+	for ip := range im.openPorts {
+		ips = append(ips, ip)
+	}
+	sortIPs(ips)
+	skipped := 0
+	for _, ip := range ips {
+		ports := []int{}
+		for port := range im.openPorts[ip] {
+			ports = append(ports, port)
+		}
+		sort.Ints(ports)
+		addServices := func(host *lair.Host) bool {
+			before := len(host.Services)
+			for _, port := range ports {
+				ensureService(host, port, "tcp", "")
+			}
+			im.metrics.PortsCreated += len(host.Services) - before
+			return len(host.Services) > before
+		}
+		stats := im.metrics.handler("OPEN_TCP_PORT")
+		if im.updateHost(ip, addServices) {
+			stats.Matched += len(ports)
+			continue
+		}
+		host := lair.Host{IPv4: ip, Tags: im.opts.hostTags, LastModifiedBy: lastModifiedBy}
+		switch {
+		case im.opts.forceHosts:
+		case im.opts.lowConfidence > 0:
+			host.Hostnames, _ = appendUnique(nil, im.bNotFound[ip]...)
+			host.Tags = im.opts.lowConfidenceTags()
+			host.Status = lair.StatusGrey
+			delete(im.bNotFound, ip)
+		default:
+			skipped++
+			stats.Skipped += len(ports)
+			continue
+		}
+		stats.Matched += len(ports)
+		addServices(&host)
+		im.project.Hosts = append(im.project.Hosts, host)
+	}
+	if skipped > 0 {
+		logf("Skipped open ports on %d hosts that do not exist in lair", skipped)
+	}
+}
+
+// applySpeculatedPorts adds a tcp service for each port the speculate module
+// guessed on a host in the project, with a note marking it unconfirmed. Ports
+// the host already has a service for, such as those confirmed by nmap, are
+// left as they are, and no host is created for a speculated port.
+func (im *importer) applySpeculatedPorts() {
+	stats := im.metrics.handler("OPEN_TCP_PORT")
+	for ip, speculated := range im.speculatedPorts {
+		ports := []int{}
+		for port := range speculated {
+			if !im.openPorts[ip][port] {
+				ports = append(ports, port)
+			}
+		}
+		sort.Ints(ports)
+		found := im.updateHost(ip, func(host *lair.Host) bool {
+			before := len(host.Services)
+			for _, port := range ports {
+				if hasService(host, port, "tcp") {
+					continue
+				}
+				service := ensureService(host, port, "tcp", "")
+				service.Notes = append(service.Notes, lair.Note{
+					Title:          unconfirmedNoteTitle,
+					Content:        fmt.Sprintf("bbot's speculate module guessed that port %d is open, it was not confirmed by a scan.", port),
+					LastModifiedBy: lastModifiedBy,
+				})
+			}
+			im.metrics.PortsCreated += len(host.Services) - before
+			return len(host.Services) > before
+		})
+		if found {
+			stats.Matched += len(ports)
+		} else {
+			stats.Skipped += len(ports)
+		}
+	}
+}
+
+// hasService reports whether host has a service with port and protocol.
+func hasService(host *lair.Host, port int, protocol string) bool {
+	for _, service := range host.Services {
+		if service.Port == port && service.Protocol == protocol {
+			return true
+		}
+	}
+	return false
+}
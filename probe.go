@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultProbePorts = "21,22,23,25,53,80,110,143,443,445,3389,8080,8443"
+
+// maxProbeRate is the highest -probe-rate, well below the rate at which the
+// interval between probes would round down to zero.
+const maxProbeRate = 10000
+
+// parsePorts converts a comma separated list of ports into a slice of ints.
+func parsePorts(list string) ([]int, error) {
+	ports := []int{}
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q", p)
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports specified")
+	}
+	return ports, nil
+}
+
+// probeCandidates returns the IPs of hosts that do not exist in Lair which
+// -probe-unmatched may scan: those resolved by a DNS_NAME event within bbot's
+// scope, and those with a hostname whose registrable domain is in -scope or
+// is a bbot target. IPs that only out of scope names resolved to are not
+// probed.
+func (im *importer) probeCandidates() []string {
+	scope := im.scopeDomains()
+	ips := []string{}
+	for ip, names := range im.bNotFound {
+		inScope := im.bInScope[ip]
+		for _, name := range names {
+			inScope = inScope || scope[registrableDomain(name)]
+		}
+		if inScope {
+			ips = append(ips, ip)
+		}
+	}
+	sortIPs(ips)
+	return ips
+}
+
+// probeHosts performs a TCP connect scan of ports against every ip, starting at
+// most rate connections per second. It returns the open ports for each ip that
+// responded, ips with no open ports are omitted.
+func probeHosts(ips []string, ports []int, rate int, timeout time.Duration) map[string][]int {
+	if rate < 1 {
+		rate = 1
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	open := make(map[string][]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ip := range ips {
+		for _, port := range ports {
+			<-ticker.C
+			wg.Add(1)
+			go func(ip string, port int) {
+				defer wg.Done()
+				conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
+				if err != nil {
+					return
+				}
+				conn.Close()
+				mu.Lock()
+				open[ip] = append(open[ip], port)
+				mu.Unlock()
+			}(ip, port)
+		}
+	}
+	wg.Wait()
+
+	for ip := range open {
+		sort.Ints(open[ip])
+	}
+	return open
+}
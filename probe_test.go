@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/lair-framework/go-lair"
+)
+
+func TestProbeCandidates(t *testing.T) {
+	events := []string{
+		`{"type":"SCAN","data":{"target":{"seeds":["example.com"]}}}`,
+		`{"type":"DNS_NAME","host":"www.example.com","resolved_hosts":["192.0.2.1"],"scope_distance":0}`,
+		`{"type":"DNS_NAME","host":"cdn.example.net","resolved_hosts":["192.0.2.2"],"scope_distance":1}`,
+		`{"type":"DNS_NAME","host":"mail.example.com","resolved_hosts":["192.0.2.3"],"scope_distance":2}`,
+		`{"type":"DNS_NAME","host":"partner.example.org","resolved_hosts":["192.0.2.4"]}`,
+		`{"type":"DNS_NAME","host":"shop.example.net","resolved_hosts":["192.0.2.5"],"scope_distance":1,"dns_children":{"CNAME":["shop.example.com"]}}`,
+		`{"type":"DNS_NAME","host":"api.example.com","resolved_hosts":["192.0.2.6"],"scope_distance":0}`,
+		`{"type":"DNS_NAME","host":"known.example.com","resolved_hosts":["192.0.2.10"],"scope_distance":0}`,
+	}
+	tests := []struct {
+		name  string
+		scope []string
+		want  []string
+	}{
+		{"bbot targets", nil, []string{"192.0.2.1", "192.0.2.3", "192.0.2.4", "192.0.2.5", "192.0.2.6"}},
+		{"scope", []string{"example.net"}, []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4", "192.0.2.5", "192.0.2.6"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := &importer{
+				opts:        &options{scope: tt.scope},
+				project:     &lair.Project{},
+				existingIPs: map[string]lair.Host{"192.0.2.10": {IPv4: "192.0.2.10"}},
+				updated:     make(map[string]bool),
+				bNotFound:   make(map[string][]string),
+				bInScope:    make(map[string]bool),
+				names:       make(map[string][]string),
+				targets:     make(map[string]bool),
+				cnames:      make(map[string]string),
+				metrics:     newMetrics(),
+			}
+			for _, event := range events {
+				var entry map[string]interface{}
+				if err := json.Unmarshal([]byte(event), &entry); err != nil {
+					t.Fatal(err)
+				}
+				im.recordTarget(entry)
+				if entry["type"] == "DNS_NAME" {
+					im.handleDNSName(entry)
+				}
+			}
+			if got := im.probeCandidates(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("probeCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
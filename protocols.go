@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// serviceProtocol is the protocol fingerprintx identified on a port.
+type serviceProtocol struct {
+	ip        string
+	port      int
+	transport string
+	name      string
+}
+
+// handleProtocol records the protocol of a PROTOCOL event, such as ssh or rdp,
+// for its port on every IP of the host.
+func (im *importer) handleProtocol(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	name, _ := data["protocol"].(string)
+	name = strings.ToLower(strings.TrimSpace(sanitizeText(name)))
+	port, err := strconv.Atoi(fmt.Sprint(data["port"]))
+	if name == "" || err != nil || port < 1 || port > 65535 {
+		return
+	}
+	transport := "tcp"
+	if t, _ := data["transport"].(string); strings.EqualFold(t, "udp") {
+		transport = "udp"
+	}
+	for _, ip := range eventIPs(entry) {
+		key := fmt.Sprintf("%s:%d/%s", ip, port, transport)
+		im.protocols[key] = &serviceProtocol{ip: ip, port: port, transport: transport, name: name}
+	}
+}
+
+// isUnnamedService reports whether Lair would replace the name of a service,
+// which it does when the name is empty, unknown or a guess ending in ?.
+func isUnnamedService(name string) bool {
+	return name == "" || strings.EqualFold(name, "unknown") || strings.Contains(name, "?")
+}
+
+// applyProtocols names the services of recorded protocols, adding services
+// that do not exist yet. Services that already have a name keep it, as Lair
+// would. Hosts that are not in the project are skipped.
+func (im *importer) applyProtocols() {
+	keys := []string{}
+	for key := range im.protocols {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := im.protocols[key]
+		im.updateHost(p.ip, func(host *lair.Host) bool {
+			service := ensureService(host, p.port, p.transport, "")
+			if !isUnnamedService(service.Service) {
+				return false
+			}
+			service.Service = p.name
+			return true
+		})
+	}
+}
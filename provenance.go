@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// provenanceMaxDepth limits how many ancestors of a finding are listed.
+const provenanceMaxDepth = 16
+
+// eventOrigin is what -provenance keeps of each event to describe how bbot
+// reached a finding.
+type eventOrigin struct {
+	parent string
+	label  string
+}
+
+// eventParent returns the ID of the event an event was discovered from. bbot
+// 2 calls it parent, earlier versions source.
+func eventParent(entry map[string]interface{}) string {
+	if parent, _ := entry["parent"].(string); parent != "" {
+		return parent
+	}
+	source, _ := entry["source"].(string)
+	return source
+}
+
+// eventLabel describes an event by its type, data and module, such as
+// "URL https://www.example.com/ (httpx)".
+func eventLabel(entry map[string]interface{}) string {
+	eventType, _ := entry["type"].(string)
+	label := eventType
+	var data string
+	switch d := entry["data"].(type) {
+	case string:
+		data = d
+	case map[string]interface{}:
+		for _, field := range []string{"url", "host", "technology"} {
+			if s, _ := d[field].(string); s != "" {
+				data = s
+				break
+			}
+		}
+	}
+	if len(data) > 100 {
+		data = data[:97] + "..."
+	}
+	if data != "" {
+		label += " " + data
+	}
+	if module, _ := entry["module"].(string); module != "" {
+		label += " (" + module + ")"
+	}
+	return label
+}
+
+// recordOrigin remembers the parent and label of an event with -provenance.
+func (im *importer) recordOrigin(entry map[string]interface{}) {
+	if !im.opts.provenance {
+		return
+	}
+	if id, _ := entry["id"].(string); id != "" {
+		im.origins[id] = eventOrigin{parent: eventParent(entry), label: sanitizeText(eventLabel(entry))}
+	}
+}
+
+// provenance returns the discovery chain of a finding with -provenance, from
+// the first event bbot found to the finding itself, or an empty string. The
+// chain follows the parents recorded during this import and falls back to the
+// event's discovery_path when none are known.
+func (im *importer) provenance(entry map[string]interface{}) string {
+	if !im.opts.provenance {
+		return ""
+	}
+	chain := []string{eventLabel(entry)}
+	seen := map[string]bool{}
+	for parent := eventParent(entry); parent != "" && !seen[parent] && len(chain) <= provenanceMaxDepth; {
+		seen[parent] = true
+		origin, found := im.origins[parent]
+		if !found {
+			break
+		}
+		chain = append(chain, origin.label)
+		parent = origin.parent
+	}
+	if len(chain) == 1 {
+		path, _ := entry["discovery_path"].([]interface{})
+		for i := len(path) - 1; i >= 0; i-- {
+			step := path[i]
+			if pair, ok := step.([]interface{}); ok && len(pair) == 2 {
+				step = pair[1]
+			}
+			if s, ok := step.(string); ok && s != "" {
+				chain = append(chain, s)
+			}
+		}
+		if len(chain) == 1 {
+			return ""
+		}
+	}
+	var b strings.Builder
+	b.WriteString("Discovery chain:")
+	for i := len(chain) - 1; i >= 0; i-- {
+		arrow := "-> "
+		if i == len(chain)-1 {
+			arrow = ""
+		}
+		fmt.Fprintf(&b, "\n  %s%s", arrow, chain[i])
+	}
+	return sanitizeText(b.String())
+}
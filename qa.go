@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// qaLookupTimeout bounds each DNS lookup made by -qa-sample.
+const qaLookupTimeout = 5 * time.Second
+
+// qaName is a hostname imported onto the host with ip.
+type qaName struct {
+	name string
+	ip   string
+}
+
+// qaReport is the result of re-resolving a sample of imported hostnames.
+type qaReport struct {
+	Sampled    int      `json:"sampled"`
+	Mismatched int      `json:"mismatched"`
+	Unresolved int      `json:"unresolved"`
+	Problems   []string `json:"problems"`
+}
+
+// importedNames returns the hostnames of created hosts, and the hostnames
+// this import added to existing hosts.
+func (im *importer) importedNames(created []lair.Host, existing []lair.Host) []qaName {
+	names := []qaName{}
+	for _, host := range created {
+		for _, name := range host.Hostnames {
+			names = append(names, qaName{name: name, ip: host.IPv4})
+		}
+	}
+	for _, host := range existing {
+		if !im.updated[host.IPv4] {
+			continue
+		}
+		for _, name := range missing(im.existingIPs[host.IPv4].Hostnames, host.Hostnames) {
+			names = append(names, qaName{name: name, ip: host.IPv4})
+		}
+	}
+	return names
+}
+
+// verifySample resolves a random sample of size names and reports those that
+// no longer resolve, or resolve without the IP of the host they were imported
+// onto.
+func verifySample(names []qaName, size int) *qaReport {
+	sample := append([]qaName{}, names...)
+	rand.Shuffle(len(sample), func(i, j int) { sample[i], sample[j] = sample[j], sample[i] })
+	if len(sample) > size {
+		sample = sample[:size]
+	}
+	report := &qaReport{Sampled: len(sample), Problems: []string{}}
+	for _, n := range sample {
+		ctx, cancel := context.WithTimeout(context.Background(), qaLookupTimeout)
+		addrs, err := net.DefaultResolver.LookupHost(ctx, n.name)
+		cancel()
+		if err != nil {
+			report.Unresolved++
+			report.Problems = append(report.Problems, fmt.Sprintf("%s (%s): does not resolve", n.name, n.ip))
+			continue
+		}
+		found := false
+		for _, addr := range addrs {
+			found = found || addr == n.ip
+		}
+		if !found {
+			report.Mismatched++
+			report.Problems = append(report.Problems, fmt.Sprintf("%s (%s): resolves to %v", n.name, n.ip, addrs))
+		}
+	}
+	return report
+}
+
+// rate returns the share of sampled hostnames that failed verification as a
+// percentage.
+func (r *qaReport) rate() float64 {
+	if r.Sampled == 0 {
+		return 0
+	}
+	return float64(r.Mismatched+r.Unresolved) * 100 / float64(r.Sampled)
+}
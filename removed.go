@@ -0,0 +1,58 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// removedTags mark hosts an analyst removed from the engagement in Lair, which
+// has no way to delete or hide a host that drone-bbot would not undo.
+var removedTags = []string{"deleted", "removed", "hidden"}
+
+// isRemovedHost reports whether host has one of the removedTags.
+func isRemovedHost(host lair.Host) bool {
+	for _, tag := range host.Tags {
+		for _, removed := range removedTags {
+			if strings.EqualFold(strings.TrimSpace(tag), removed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordRemovedSighting remembers that bbot found dnsName on a removed host.
+func (im *importer) recordRemovedSighting(host lair.Host, dnsName string) {
+	if isRemovedHost(host) {
+		im.removedSightings[host.IPv4], _ = appendUnique(im.removedSightings[host.IPv4], dnsName)
+	}
+}
+
+// applyRemovedHosts leaves existing hosts with a removedTag out of the import,
+// so that hostnames and services are not added back to them, and returns what
+// bbot found on each of them keyed by IP for an analyst to decide whether the
+// host is back in scope.
+func (im *importer) applyRemovedHosts(existing []lair.Host) map[string][]string {
+	removed := make(map[string][]string)
+	for _, host := range existing {
+		if !isRemovedHost(host) {
+			continue
+		}
+		found := []string{}
+		if names := im.removedSightings[host.IPv4]; len(names) > 0 {
+			sort.Strings(names)
+			found = append(found, "found "+strings.Join(names, ", "))
+		}
+		if im.updated[host.IPv4] {
+			found = append(found, hostChanges(host, im.existingIPs[host.IPv4])...)
+			delete(im.updated, host.IPv4)
+		}
+		if len(found) > 0 {
+			removed[host.IPv4] = found
+		}
+		delete(im.existingIPs, host.IPv4)
+	}
+	return removed
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// ipSortKey orders IPv4 addresses before IPv6 addresses, and both before
+// values that are not IPs.
+func ipSortKey(s string) (int, []byte) {
+	ip := net.ParseIP(s)
+	switch {
+	case ip == nil:
+		return 2, []byte(s)
+	case ip.To4() != nil:
+		return 0, ip.To4()
+	default:
+		return 1, ip.To16()
+	}
+}
+
+// sortIPs sorts ips numerically, so that 10.0.0.2 comes before 10.0.0.10 and
+// the order does not depend on the locale of whoever reads the report.
+func sortIPs(ips []string) {
+	sort.SliceStable(ips, func(i, j int) bool {
+		ki, bi := ipSortKey(ips[i])
+		kj, bj := ipSortKey(ips[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return bytes.Compare(bi, bj) < 0
+	})
+}
+
+// notFoundTable formats the hosts that had DNS names but do not exist in Lair
+// as aligned columns, ordered by IP with each host's names sorted.
+func notFoundTable(notFound map[string][]string) []string {
+	ips := []string{}
+	for ip := range notFound {
+		ips = append(ips, ip)
+	}
+	sortIPs(ips)
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IP\tDNS NAMES")
+	for _, ip := range ips {
+		names, _ := appendUnique(nil, notFound[ip]...)
+		sort.Strings(names)
+		fmt.Fprintf(w, "%s\t%s\n", ip, strings.Join(names, ", "))
+	}
+	w.Flush()
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+// notFoundNoteLimit caps the size of the -not-found-note content.
+const notFoundNoteLimit = 32 << 10
+
+// notFoundNote summarizes the hosts that had DNS names but do not exist in
+// Lair as a project note, grouped by registrable domain with counts. Domains
+// with the most hosts come first, and domains beyond notFoundNoteLimit are
+// only counted. The title is dated so that each day's imports add a note.
+func notFoundNote(notFound map[string][]string, now time.Time) lair.Note {
+	byDomain := make(map[string]map[string][]string)
+	for ip, names := range notFound {
+		for _, name := range names {
+			domain := registrableDomain(name)
+			if byDomain[domain] == nil {
+				byDomain[domain] = make(map[string][]string)
+			}
+			byDomain[domain][ip], _ = appendUnique(byDomain[domain][ip], name)
+		}
+	}
+	domains := []string{}
+	for domain := range byDomain {
+		domains = append(domains, domain)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if len(byDomain[domains[i]]) != len(byDomain[domains[j]]) {
+			return len(byDomain[domains[i]]) > len(byDomain[domains[j]])
+		}
+		return domains[i] < domains[j]
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d hosts had DNS names in %d domains but do not exist in Lair:\n", len(notFound), len(domains))
+	for i, domain := range domains {
+		var section strings.Builder
+		fmt.Fprintf(&section, "\n%s (%d hosts)\n", domain, len(byDomain[domain]))
+		for _, line := range notFoundTable(byDomain[domain])[1:] {
+			fmt.Fprintf(&section, "  %s\n", line)
+		}
+		if b.Len()+section.Len() > notFoundNoteLimit {
+			fmt.Fprintf(&b, "\n%d more domains are not shown.\n", len(domains)-i)
+			break
+		}
+		b.WriteString(section.String())
+	}
+	return lair.Note{
+		Title:          "drone-bbot: hosts not in lair " + now.UTC().Format("2006-01-02"),
+		Content:        sanitizeText(b.String()),
+		LastModifiedBy: lastModifiedBy,
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// resolutionChange records a hostname that Lair has on oldIP but that bbot
+// resolved to newIPs, none of which are oldIP.
+type resolutionChange struct {
+	name   string
+	oldIP  string
+	newIPs []string
+}
+
+// hostnameIndex maps each lower case hostname in hosts to the IPs it is
+// recorded on.
+func hostnameIndex(hosts []lair.Host) map[string][]string {
+	index := make(map[string][]string)
+	for _, host := range hosts {
+		for _, name := range host.Hostnames {
+			name = strings.ToLower(name)
+			index[name] = append(index[name], host.IPv4)
+		}
+	}
+	return index
+}
+
+// resolutionChanges compares the IPs that name resolved to against the IPs it
+// is recorded on in Lair.
+func resolutionChanges(index map[string][]string, name string, resolved []string) []resolutionChange {
+	changes := []resolutionChange{}
+	for _, oldIP := range index[strings.ToLower(name)] {
+		stillResolves := false
+		for _, ip := range resolved {
+			if ip == oldIP {
+				stillResolves = true
+				break
+			}
+		}
+		if !stillResolves {
+			changes = append(changes, resolutionChange{name: name, oldIP: oldIP, newIPs: resolved})
+		}
+	}
+	return changes
+}
+
+// addResolutionNote adds a note describing change to host unless it already has
+// one for the same hostname and addresses. It reports whether host was modified.
+// Lair keeps only the first note with a given title, so the title identifies
+// the change.
+func addResolutionNote(host *lair.Host, change resolutionChange, seen time.Time) bool {
+	title := fmt.Sprintf("drone-bbot: %s resolution changed from %s to %s", change.name, change.oldIP, strings.Join(change.newIPs, ", "))
+	for _, note := range host.Notes {
+		if note.Title == title {
+			return false
+		}
+	}
+	host.Notes = append(host.Notes, lair.Note{
+		Title:          title,
+		Content:        fmt.Sprintf("%s previously resolved to %s and now resolves to %s.\nObserved at %s\n", change.name, change.oldIP, strings.Join(change.newIPs, ", "), seen.UTC().Format(time.RFC3339)),
+		LastModifiedBy: lastModifiedBy,
+	})
+	return true
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+const reviewHelp = `Commands:
+  h               list hosts, the view shown first
+  f               list findings, the issues to import
+  l               list the current view (limited to the current search, if any)
+  /<text>         search the current view by IP, hostname or tag of hosts, or by
+                  title, rating or IP of findings, "/" clears the search
+  x <selection>   exclude from the current view, e.g. "x 1,3-5", "x *" excludes the
+                  search results
+  i <selection>   include, accepts the same selections as x
+  d               done, import the included hosts and findings
+  q               quit without importing
+  ?               list these commands
+`
+
+// reviewView is a list of hosts or findings in the review, with the line
+// listing each item, the fields searched and whether it is included.
+type reviewView struct {
+	name     string
+	lines    []string
+	fields   [][]string
+	included []bool
+}
+
+func newReviewView(name string, count int) *reviewView {
+	v := &reviewView{name: name, included: make([]bool, count)}
+	for i := range v.included {
+		v.included[i] = true
+	}
+	return v
+}
+
+// matches returns the indexes of the items containing the lower case search
+// term in one of their fields, or every item when term is empty.
+func (v *reviewView) matches(term string) []int {
+	idx := []int{}
+	for i, fields := range v.fields {
+		if term == "" || containsTerm(fields, term) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// reviewImport lets the user curate the hosts and issues of an import before
+// they are imported. It reads commands from in and writes to out, and returns
+// the included hosts and issues and whether the import should proceed. An
+// issue keeps the hosts it affects when some of them are excluded.
+func reviewImport(in io.Reader, out io.Writer, hosts []lair.Host, issues []lair.Issue) ([]lair.Host, []lair.Issue, bool) {
+	hostView := newReviewView("hosts", len(hosts))
+	for _, host := range hosts {
+		hostView.lines = append(hostView.lines, fmt.Sprintf("%-15s %s %v", host.IPv4, strings.Join(host.Hostnames, ","), host.Tags))
+		hostView.fields = append(hostView.fields, append(append([]string{host.IPv4}, host.Hostnames...), host.Tags...))
+	}
+	issueView := newReviewView("findings", len(issues))
+	for _, issue := range issues {
+		fields := []string{issue.Title, issue.Rating}
+		for _, host := range issue.Hosts {
+			fields = append(fields, host.IPv4)
+		}
+		issueView.lines = append(issueView.lines, fmt.Sprintf("%-8s %s (%d hosts)", issue.Rating, issue.Title, len(issue.Hosts)))
+		issueView.fields = append(issueView.fields, fields)
+	}
+
+	view := hostView
+	search := ""
+	list := func() {
+		for _, i := range view.matches(search) {
+			mark := "x"
+			if !view.included[i] {
+				mark = " "
+			}
+			fmt.Fprintf(out, "%4d [%s] %s\n", i+1, mark, view.lines[i])
+		}
+	}
+
+	fmt.Fprint(out, reviewHelp)
+	list()
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "%s> ", view.name)
+		if !scanner.Scan() {
+			return nil, nil, false
+		}
+		cmd := strings.TrimSpace(scanner.Text())
+		switch {
+		case cmd == "":
+		case cmd == "h", cmd == "f":
+			view = hostView
+			if cmd == "f" {
+				view = issueView
+			}
+			search = ""
+			list()
+		case cmd == "l":
+			list()
+		case cmd == "d":
+			selectedHosts := []lair.Host{}
+			for i, host := range hosts {
+				if hostView.included[i] {
+					selectedHosts = append(selectedHosts, host)
+				}
+			}
+			selectedIssues := []lair.Issue{}
+			for i, issue := range issues {
+				if issueView.included[i] {
+					selectedIssues = append(selectedIssues, issue)
+				}
+			}
+			return selectedHosts, selectedIssues, true
+		case cmd == "q":
+			return nil, nil, false
+		case strings.HasPrefix(cmd, "/"):
+			search = strings.ToLower(strings.TrimPrefix(cmd, "/"))
+			list()
+		case strings.HasPrefix(cmd, "x ") || strings.HasPrefix(cmd, "i "):
+			selection, err := parseSelection(strings.TrimSpace(cmd[2:]), len(view.included), view.matches(search))
+			if err != nil {
+				fmt.Fprintf(out, "Error: %s\n", err.Error())
+				continue
+			}
+			for _, i := range selection {
+				view.included[i] = cmd[0] == 'i'
+			}
+			fmt.Fprintf(out, "%d %s updated\n", len(selection), view.name)
+		default:
+			fmt.Fprint(out, reviewHelp)
+		}
+	}
+}
+
+// containsTerm reports whether any of fields contains the lower case search
+// term.
+func containsTerm(fields []string, term string) bool {
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSelection converts a selection of one based indexes and ranges such as
+// "1,3-5" into zero based indexes. "*" selects the current search results.
+func parseSelection(sel string, count int, current []int) ([]int, error) {
+	if sel == "*" {
+		return current, nil
+	}
+	idx := []int{}
+	for _, part := range strings.Split(sel, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", part)
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("selection %q is out of range", part)
+		}
+		for i := start; i <= end; i++ {
+			idx = append(idx, i-1)
+		}
+	}
+	return idx, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	current := []int{1, 4}
+	tests := []struct {
+		sel     string
+		want    []int
+		wantErr bool
+	}{
+		{"1", []int{0}, false},
+		{"1,3-5", []int{0, 2, 3, 4}, false},
+		{" 2 , 5", []int{1, 4}, false},
+		{"*", current, false},
+		{"0", nil, true},
+		{"6", nil, true},
+		{"4-2", nil, true},
+		{"1-x", nil, true},
+		{"a", nil, true},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sel, func(t *testing.T) {
+			got, err := parseSelection(tt.sel, 5, current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSelection(%q) error = %v, want error %v", tt.sel, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSelection(%q) = %v, want %v", tt.sel, got, tt.want)
+			}
+		})
+	}
+}
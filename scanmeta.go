@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanPresetFiles are the files in a bbot scan directory that hold the scan
+// configuration, in order of preference.
+var scanPresetFiles = []string{"preset.yml", "preset.yaml", "config.yml"}
+
+// assetInventoryFile is the file bbot's asset_inventory module writes to the
+// scan directory.
+const assetInventoryFile = "asset-inventory.csv"
+
+// scanMeta describes a bbot scan for the Lair command entry of its import.
+type scanMeta struct {
+	name    string
+	targets []string
+	modules []string
+	started string
+}
+
+// String describes the scan, such as "bbot -t example.com -m httpx,portscan
+// (scan demonic_jimmy, started 2024-05-01 10:00:00 UTC)".
+func (m *scanMeta) String() string {
+	parts := []string{"bbot"}
+	if len(m.targets) > 0 {
+		parts = append(parts, "-t "+strings.Join(m.targets, " "))
+	}
+	if len(m.modules) > 0 {
+		parts = append(parts, "-m "+strings.Join(m.modules, ","))
+	}
+	details := []string{}
+	if m.name != "" {
+		details = append(details, "scan "+m.name)
+	}
+	if m.started != "" {
+		details = append(details, "started "+m.started)
+	}
+	if len(details) > 0 {
+		parts = append(parts, "("+strings.Join(details, ", ")+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// clone returns a copy of m, or empty metadata when m is nil.
+func (m *scanMeta) clone() *scanMeta {
+	if m == nil {
+		return &scanMeta{}
+	}
+	c := *m
+	c.targets = append([]string{}, m.targets...)
+	c.modules = append([]string{}, m.modules...)
+	return &c
+}
+
+// empty reports whether nothing is known about the scan.
+func (m *scanMeta) empty() bool {
+	return m.name == "" && len(m.targets) == 0 && len(m.modules) == 0 && m.started == ""
+}
+
+// readScanPreset reads the scan name, targets and modules from the preset
+// bbot writes to the scan directory dir. A directory without a preset has
+// no metadata.
+func readScanPreset(dir string) (*scanMeta, error) {
+	meta := &scanMeta{}
+	for _, name := range scanPresetFiles {
+		f, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return meta, parsePreset(f, meta)
+	}
+	return meta, nil
+}
+
+// parsePreset reads the top level scan_name, target and modules keys of a
+// bbot preset. Only the YAML needed for them is understood: scalars and
+// lists of scalars, in block or flow style.
+func parsePreset(r io.Reader, meta *scanMeta) error {
+	key := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '-' {
+			var value string
+			key, value, _ = strings.Cut(trimmed, ":")
+			meta.add(key, presetValues(value)...)
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok && key != "" {
+			meta.add(key, presetValues(item)...)
+		}
+	}
+	return scanner.Err()
+}
+
+// presetValues returns the scalars of a YAML value, a scalar or a flow list.
+func presetValues(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		values := []string{}
+		for _, v := range strings.Split(value[1:len(value)-1], ",") {
+			values = append(values, presetValues(v)...)
+		}
+		return values
+	}
+	return []string{strings.Trim(value, `"'`)}
+}
+
+// add records the values of a preset key or SCAN event field.
+func (m *scanMeta) add(key string, values ...string) {
+	for _, value := range values {
+		value = sanitizeText(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		switch key {
+		case "scan_name", "name":
+			if m.name == "" {
+				m.name = value
+			}
+		case "target", "targets", "seeds":
+			m.targets, _ = appendUnique(m.targets, value)
+		case "modules":
+			m.modules, _ = appendUnique(m.modules, value)
+		}
+	}
+}
+
+// recordScan completes the scan metadata from the SCAN event bbot writes at
+// the start of a scan: its name, targets, modules and start time.
+func (im *importer) recordScan(entry map[string]interface{}) {
+	if entry["type"] != "SCAN" {
+		return
+	}
+	meta := im.scanMeta
+	data, _ := entry["data"].(map[string]interface{})
+	if name, ok := data["name"].(string); ok {
+		meta.add("name", name)
+	}
+	switch target := data["target"].(type) {
+	case map[string]interface{}:
+		meta.add("seeds", stringValues(target["seeds"])...)
+	default:
+		meta.add("target", stringValues(target)...)
+	}
+	if preset, ok := data["preset"].(map[string]interface{}); ok {
+		meta.add("modules", stringValues(preset["modules"])...)
+	}
+	if meta.started != "" {
+		return
+	}
+	if started, _ := data["started_at"].(string); started != "" {
+		meta.started = sanitizeText(started)
+	} else if ts, ok := entry["timestamp"].(float64); ok && ts > 0 {
+		meta.started = time.Unix(int64(ts), 0).UTC().Format("2006-01-02 15:04:05 MST")
+	}
+}
+
+// stringValues returns the strings of a JSON string or array of strings.
+func stringValues(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := []string{}
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// commandText returns the command recorded for the import: the
+// -command-label and the description of the scan, when either is known.
+func commandText(label string, meta *scanMeta) string {
+	if meta == nil || meta.empty() {
+		return label
+	}
+	if label == "" {
+		return meta.String()
+	}
+	return label + ": " + meta.String()
+}
+
+// assetInventoryEvents converts the rows of bbot's asset-inventory.csv into
+// DNS_NAME events with the IPs of each host and OPEN_TCP_PORT events for its
+// open ports, for scan directories that only hold the inventory.
+func assetInventoryEvents(r io.Reader, emit func(map[string]interface{}) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	hostColumn, portsColumn, ipColumns := -1, -1, []int{}
+	for i, name := range header {
+		switch name = strings.ToLower(strings.TrimSpace(name)); {
+		case name == "host":
+			hostColumn = i
+		case name == "open ports":
+			portsColumn = i
+		case strings.HasPrefix(name, "ip"):
+			ipColumns = append(ipColumns, i)
+		}
+	}
+	if hostColumn < 0 {
+		return fmt.Errorf("%s has no Host column", assetInventoryFile)
+	}
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' })
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hostColumn >= len(row) || strings.TrimSpace(row[hostColumn]) == "" {
+			continue
+		}
+		host := strings.TrimSpace(row[hostColumn])
+		resolved := []interface{}{}
+		for _, i := range ipColumns {
+			if i < len(row) {
+				for _, ip := range split(row[i]) {
+					resolved = append(resolved, ip)
+				}
+			}
+		}
+		event := map[string]interface{}{"type": "DNS_NAME", "data": host, "host": host, "resolved_hosts": resolved, "module": "asset_inventory"}
+		if err := emit(event); err != nil {
+			return err
+		}
+		if portsColumn < 0 || portsColumn >= len(row) {
+			continue
+		}
+		for _, port := range split(row[portsColumn]) {
+			if _, err := strconv.Atoi(port); err != nil {
+				continue
+			}
+			event := map[string]interface{}{"type": "OPEN_TCP_PORT", "data": host + ":" + port, "host": host, "resolved_hosts": resolved, "module": "asset_inventory"}
+			if err := emit(event); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// registrableDomain returns the domain under which name is registered, such as
+// example.co.uk for www.example.co.uk, according to the public suffix list.
+// Names that are a public suffix themselves are returned unchanged.
+func registrableDomain(name string) string {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return name
+	}
+	return domain
+}
+
+// recordTarget remembers the registrable domains of bbot's targets, from the
+// seeds and whitelist of SCAN events and from events emitted by the TARGET
+// module.
+func (im *importer) recordTarget(entry map[string]interface{}) {
+	targets := []interface{}{}
+	switch {
+	case entry["type"] == "SCAN":
+		data, _ := entry["data"].(map[string]interface{})
+		target, _ := data["target"].(map[string]interface{})
+		for _, field := range []string{"seeds", "whitelist"} {
+			values, _ := target[field].([]interface{})
+			targets = append(targets, values...)
+		}
+	case entry["module"] == "TARGET" && entry["type"] == "DNS_NAME":
+		targets = append(targets, entry["host"])
+	}
+	for _, target := range targets {
+		if name, ok := target.(string); ok && strings.Contains(name, ".") && !strings.Contains(name, "/") {
+			im.targets[registrableDomain(name)] = true
+		}
+	}
+}
+
+// outsideScope reports whether bbot placed the event outside of its scope,
+// with a scope_distance above 0. Events without a distance are in scope.
+func outsideScope(entry map[string]interface{}) bool {
+	distance, ok := entry["scope_distance"].(float64)
+	return ok && distance > 0
+}
+
+// scopeDomains returns the registrable domains of -scope and bbot's targets.
+func (im *importer) scopeDomains() map[string]bool {
+	scope := make(map[string]bool)
+	for target := range im.targets {
+		scope[target] = true
+	}
+	for _, domain := range im.opts.scope {
+		scope[registrableDomain(domain)] = true
+	}
+	return scope
+}
+
+// applyScopeGuard removes the hostnames -force-hosts created hosts for whose
+// registrable domain is neither in -scope nor a bbot target, and the hosts
+// left without a hostname, unless -allow-foreign-domains is set. The guard is
+// skipped with a warning when there is no scope to check against.
+func (im *importer) applyScopeGuard() {
+	if !im.opts.forceHosts || im.opts.allowForeignDomains {
+		return
+	}
+	scope := im.scopeDomains()
+	if len(scope) == 0 {
+		logf("Warning: no -scope or bbot targets, -force-hosts imports hostnames of any domain")
+		return
+	}
+	kept := im.project.Hosts[:0]
+	foreign := make(map[string]bool)
+	for _, host := range im.project.Hosts {
+		if len(host.Hostnames) == 0 {
+			kept = append(kept, host)
+			continue
+		}
+		names := []string{}
+		for _, name := range host.Hostnames {
+			if scope[registrableDomain(name)] {
+				names = append(names, name)
+			} else {
+				foreign[registrableDomain(name)] = true
+			}
+		}
+		if len(names) > 0 {
+			host.Hostnames = names
+			kept = append(kept, host)
+		}
+	}
+	im.project.Hosts = kept
+	if len(foreign) > 0 {
+		domains := []string{}
+		for domain := range foreign {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		logf("Skipped hostnames outside of the scope, use -allow-foreign-domains to import them: %s", strings.Join(domains, ", "))
+	}
+}
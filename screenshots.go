@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+// screenshotModes are the values accepted by -screenshots.
+var screenshotModes = []string{"", "note", "upload"}
+
+const screenshotNotePrefix = "drone-bbot: screenshot "
+
+// maxScreenshotSize is the largest screenshot uploaded, Lair rejects files
+// over 30MB.
+const maxScreenshotSize = 30 << 20
+
+// screenshot is a WEBSCREENSHOT taken by bbot's gowitness module of a web
+// service.
+type screenshot struct {
+	ip     string
+	port   int
+	scheme string
+	url    string
+	path   string
+}
+
+// screenshotPath returns the local path of a screenshot bbot wrote to path.
+// bbot records absolute paths on the machine that ran the scan, so with
+// -scan-dir the part of path below the scan directory's name is looked up in
+// the -scan-dir instead.
+func screenshotPath(scanDir, path string) string {
+	if scanDir == "" {
+		return path
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(scanDir, path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	base := filepath.Base(filepath.Clean(scanDir))
+	for i, part := range parts {
+		if part == base {
+			return filepath.Join(append([]string{scanDir}, parts[i+1:]...)...)
+		}
+	}
+	return path
+}
+
+// handleWebScreenshot records the screenshot of a WEBSCREENSHOT event for the
+// web service of its URL when -screenshots is set.
+func (im *importer) handleWebScreenshot(entry map[string]interface{}) {
+	if im.opts.screenshots == "" {
+		return
+	}
+	data, _ := entry["data"].(map[string]interface{})
+	path, _ := data["path"].(string)
+	rawURL, _ := data["url"].(string)
+	u, err := url.Parse(rawURL)
+	if path == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	port := urlPort(rawURL)
+	for _, ip := range im.webIPs(entry) {
+		im.screenshots[ip+" "+rawURL] = &screenshot{
+			ip:     ip,
+			port:   port,
+			scheme: u.Scheme,
+			url:    sanitizeText(rawURL),
+			path:   screenshotPath(im.opts.scanDir, path),
+		}
+	}
+}
+
+// sortedScreenshots returns the recorded screenshots sorted by IP and URL.
+func (im *importer) sortedScreenshots() []*screenshot {
+	keys := []string{}
+	for key := range im.screenshots {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	screenshots := []*screenshot{}
+	for _, key := range keys {
+		screenshots = append(screenshots, im.screenshots[key])
+	}
+	return screenshots
+}
+
+// applyScreenshots adds a note referencing each recorded screenshot to the
+// web service it shows. Services on IPs that are not hosts in the project are
+// skipped.
+func (im *importer) applyScreenshots() {
+	for _, shot := range im.sortedScreenshots() {
+		im.updateHost(shot.ip, func(host *lair.Host) bool {
+			service := ensureService(host, shot.port, "tcp", shot.scheme)
+			title := screenshotNotePrefix + shot.url
+			if hasNote(service.Notes, title) {
+				return false
+			}
+			content := fmt.Sprintf("Screenshot of %s taken by bbot's gowitness module:\n\n%s\n", shot.url, shot.path)
+			if im.opts.screenshots == "upload" {
+				content += "\nThe image is uploaded to the files of this service, or of the host when Lair has no such service.\n"
+			}
+			service.Notes = append(service.Notes, lair.Note{
+				Title:          title,
+				Content:        sanitizeText(content),
+				LastModifiedBy: lastModifiedBy,
+			})
+			return true
+		})
+	}
+}
+
+// fileNameKey normalizes a file name for comparison with the names Lair
+// stores, which it sanitizes on upload.
+func fileNameKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(filepath.Base(name)))
+}
+
+// hasFile reports whether files contains a file named name.
+func hasFile(files []lair.File, name string) bool {
+	for _, f := range files {
+		if fileNameKey(f.FileName) == fileNameKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadScreenshots uploads the recorded screenshots to project lairPID after
+// an import, attaching each to its web service, or to its host when Lair has
+// no such service. The project is exported again for the IDs of the hosts and
+// services created by the import, and screenshots already attached are
+// skipped. It returns the number of screenshots uploaded.
+func (im *importer) uploadScreenshots(c *client.C, lairPID string) (int, error) {
+	project, err := c.ExportProject(lairPID)
+	if err != nil {
+		return 0, fmt.Errorf("unable to export project: %s", err.Error())
+	}
+	hosts := make(map[string]lair.Host)
+	for _, host := range project.Hosts {
+		hosts[host.IPv4] = host
+	}
+	uploaded := 0
+	for _, shot := range im.sortedScreenshots() {
+		host, found := hosts[shot.ip]
+		if !found {
+			continue
+		}
+		field, id, files := "host_id", host.ID, host.Files
+		for _, service := range host.Services {
+			if service.Port == shot.port && service.Protocol == "tcp" && service.ID != "" {
+				field, id, files = "service_id", service.ID, service.Files
+			}
+		}
+		if id == "" || hasFile(files, shot.path) {
+			continue
+		}
+		if err := uploadFile(c, lairPID, field, id, shot.path); err != nil {
+			logf("Error: Unable to upload screenshot %s. Error %s", shot.path, err.Error())
+			continue
+		}
+		uploaded++
+	}
+	return uploaded, nil
+}
+
+// uploadFile uploads the file at path to project lairPID, attaching it to the
+// host or service whose ID is id. field is host_id or service_id.
+func uploadFile(c *client.C, lairPID, field, id, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		return err
+	} else if info.Size() > maxScreenshotSize {
+		return fmt.Errorf("file is larger than %d bytes", maxScreenshotSize)
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if err := w.WriteField(field, id); err != nil {
+		return err
+	}
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	reqURL := &url.URL{Scheme: c.Scheme, Host: c.Host, Path: "/api/projects/" + lairPID + "/files"}
+	req, err := http.NewRequest(http.MethodPost, reqURL.String(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.SetBasicAuth(c.User, c.Password)
+	res, err := (&http.Client{Transport: c.Transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+		return fmt.Errorf("Lair responded %d: %s", res.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
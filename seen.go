@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+const seenNotePrefix = "drone-bbot: hostnames seen "
+
+// seenRecord is the first and last date a hostname was seen on a host, and
+// with -retire-missing the number of consecutive imports since that did not
+// see it, up to the -retire-missing threshold.
+type seenRecord struct {
+	firstSeen string
+	lastSeen  string
+	missed    int
+}
+
+// latestSeenNote returns the most recent hostnames seen note on host.
+func latestSeenNote(host lair.Host) (lair.Note, bool) {
+	var latest lair.Note
+	found := false
+	for _, note := range host.Notes {
+		if strings.HasPrefix(note.Title, seenNotePrefix) && (!found || note.Title > latest.Title) {
+			latest = note
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// parseSeenNote reads the hostname records from a hostnames seen note. Each
+// line holds a hostname followed by key=value fields.
+func parseSeenNote(content string) map[string]*seenRecord {
+	records := make(map[string]*seenRecord)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		record := &seenRecord{}
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "first-seen":
+				record.firstSeen = kv[1]
+			case "last-seen":
+				record.lastSeen = kv[1]
+			case "missed":
+				record.missed, _ = strconv.Atoi(kv[1])
+			}
+		}
+		if record.firstSeen != "" {
+			records[fields[0]] = record
+		}
+	}
+	return records
+}
+
+// formatSeenNote writes records in the format read by parseSeenNote.
+func formatSeenNote(records map[string]*seenRecord) string {
+	names := []string{}
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		r := records[name]
+		fmt.Fprintf(&b, "%s first-seen=%s last-seen=%s missed=%d\n", name, r.firstSeen, r.lastSeen, r.missed)
+	}
+	return b.String()
+}
+
+// recordSeen remembers that bbot reported hostname on ip during this import.
+func (im *importer) recordSeen(ip, hostname string) {
+	if !im.opts.trackSeen {
+		return
+	}
+	if im.seen[ip] == nil {
+		im.seen[ip] = make(map[string]bool)
+	}
+	im.seen[ip][hostname] = true
+}
+
+// applySeen adds a note titled with the time of this import to every host
+// with hostnames seen during it, carrying forward the first and last seen dates
+// of every hostname in the host's previous note. Lair keeps the first note of
+// a title and never removes notes on import, so the latest note is the current
+// record and earlier notes form its history. To keep that history short, a
+// note is only added when its records differ from the previous note: when a
+// hostname is new, seen on a later day or, with -retire-missing, missing from
+// one more import.
+//
+// With -retire-missing, hosts that have a previous note are updated even when
+// none of their hostnames were seen, counting the consecutive imports each
+// hostname was missing from until the count reaches -retire-missing. Such
+// hostnames are tagged retired:<hostname>, within the tag namespace, and
+// described in a dated note.
+func (im *importer) applySeen(now time.Time) {
+	today := now.UTC().Format("2006-01-02")
+	title := seenNotePrefix + now.UTC().Format(time.RFC3339)
+	ips := []string{}
+	for ip := range im.seen {
+		ips = append(ips, ip)
+	}
+	if im.opts.retireMissing > 0 {
+		for ip, host := range im.existingIPs {
+			if _, found := latestSeenNote(host); found && im.seen[ip] == nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	for _, ip := range ips {
+		names := im.seen[ip]
+		im.updateHost(ip, func(host *lair.Host) bool {
+			if hasNote(host.Notes, title) {
+				return false
+			}
+			records := make(map[string]*seenRecord)
+			previous, found := latestSeenNote(*host)
+			if found {
+				records = parseSeenNote(previous.Content)
+			}
+			for name, record := range records {
+				if !names[name] && record.missed < im.opts.retireMissing {
+					record.missed++
+				}
+			}
+			for name := range names {
+				if records[name] == nil {
+					records[name] = &seenRecord{firstSeen: today}
+				}
+				records[name].lastSeen = today
+				records[name].missed = 0
+			}
+			content := formatSeenNote(records)
+			if found && content == previous.Content {
+				return false
+			}
+			host.Notes = append(host.Notes, lair.Note{
+				Title:          title,
+				Content:        content,
+				LastModifiedBy: lastModifiedBy,
+			})
+			if im.opts.retireMissing > 0 {
+				im.retire(host, records, today)
+			}
+			return true
+		})
+	}
+}
+
+// retire tags and notes the hostnames on host that have been missing for at
+// least -retire-missing consecutive imports. Nothing is removed from the host.
+func (im *importer) retire(host *lair.Host, records map[string]*seenRecord, today string) {
+	for name, record := range records {
+		if record.missed < im.opts.retireMissing {
+			continue
+		}
+		var added bool
+		host.Tags, added = appendUnique(host.Tags, im.opts.namespaceTag("retired:"+name))
+		if !added {
+			continue
+		}
+		host.Notes = append(host.Notes, lair.Note{
+			Title: "drone-bbot: " + name + " retired",
+			Content: fmt.Sprintf("%s was retired on %s after it was missing from %d consecutive imports. It was last seen on %s.\n",
+				name, today, record.missed, record.lastSeen),
+			LastModifiedBy: lastModifiedBy,
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// seenImport runs applySeen for one import of the hostnames in seen into a
+// project holding host, and returns the host as Lair would store it.
+func seenImport(opts *options, host lair.Host, now time.Time, seen ...string) (lair.Host, bool) {
+	im := &importer{
+		opts:        opts,
+		project:     &lair.Project{},
+		existingIPs: map[string]lair.Host{host.IPv4: host},
+		updated:     make(map[string]bool),
+		seen:        make(map[string]map[string]bool),
+	}
+	for _, name := range seen {
+		im.recordSeen(host.IPv4, name)
+	}
+	im.applySeen(now)
+	return im.existingIPs[host.IPv4], im.updated[host.IPv4]
+}
+
+func seenNotes(host lair.Host) int {
+	n := 0
+	for _, note := range host.Notes {
+		if strings.HasPrefix(note.Title, seenNotePrefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestApplySeen(t *testing.T) {
+	opts := &options{trackSeen: true}
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	host := lair.Host{IPv4: "192.0.2.1"}
+
+	steps := []struct {
+		name    string
+		now     time.Time
+		seen    []string
+		updated bool
+		notes   int
+	}{
+		{"first import", day, []string{"www.example.com"}, true, 1},
+		{"same names later that day", day.Add(time.Hour), []string{"www.example.com"}, false, 1},
+		{"new hostname", day.Add(2 * time.Hour), []string{"www.example.com", "app.example.com"}, true, 2},
+		{"hostname missing", day.Add(3 * time.Hour), []string{"www.example.com"}, false, 2},
+		{"seen the next day", day.Add(24 * time.Hour), []string{"www.example.com"}, true, 3},
+	}
+	for _, step := range steps {
+		var updated bool
+		host, updated = seenImport(opts, host, step.now, step.seen...)
+		if updated != step.updated || seenNotes(host) != step.notes {
+			t.Fatalf("%s: updated %v with %d notes, want %v with %d", step.name, updated, seenNotes(host), step.updated, step.notes)
+		}
+	}
+	note, _ := latestSeenNote(host)
+	records := parseSeenNote(note.Content)
+	if r := records["www.example.com"]; r == nil || r.firstSeen != "2026-10-01" || r.lastSeen != "2026-10-02" {
+		t.Errorf("www.example.com record = %+v, want first seen 2026-10-01 and last seen 2026-10-02", r)
+	}
+	if r := records["app.example.com"]; r == nil || r.lastSeen != "2026-10-01" || r.missed != 0 {
+		t.Errorf("app.example.com record = %+v, want last seen 2026-10-01 without missed imports", r)
+	}
+}
+
+func TestRetireMissing(t *testing.T) {
+	opts := &options{trackSeen: true, retireMissing: 2, tagNamespace: "bbot:"}
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	host := lair.Host{IPv4: "192.0.2.1"}
+	host, _ = seenImport(opts, host, now, "www.example.com", "old.example.com")
+
+	wantNotes := []int{2, 3, 3, 3}
+	for i, want := range wantNotes {
+		now = now.Add(time.Hour)
+		host, _ = seenImport(opts, host, now, "www.example.com")
+		if got := seenNotes(host); got != want {
+			t.Fatalf("import %d: %d seen notes, want %d", i+2, got, want)
+		}
+	}
+	note, _ := latestSeenNote(host)
+	if r := parseSeenNote(note.Content)["old.example.com"]; r == nil || r.missed != 2 {
+		t.Errorf("old.example.com record = %+v, want 2 missed imports", r)
+	}
+	retired := 0
+	for _, tag := range host.Tags {
+		if tag == "bbot:retired:old.example.com" {
+			retired++
+		}
+	}
+	if retired != 1 {
+		t.Errorf("tags = %v, want bbot:retired:old.example.com once", host.Tags)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/lair-framework/api-server/client"
+)
+
+// serveTokenEnv is the environment variable holding the shared token clients
+// of serve send as "Authorization: Bearer <token>".
+const serveTokenEnv = "DRONE_BBOT_SERVE_TOKEN"
+
+// serveConfig restricts who may import through serve, into which projects and
+// how much.
+type serveConfig struct {
+	token    string
+	projects map[string]bool
+	maxBody  int64
+}
+
+// authorized reports whether r carries the shared token, comparing digests in
+// constant time so that neither the token nor its length leaks through timing.
+func (cfg *serveConfig) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	got, want := sha256.Sum256([]byte(token)), sha256.Sum256([]byte(cfg.token))
+	return subtle.ConstantTimeCompare(got[:], want[:]) == 1
+}
+
+// importHandler accepts bbot NDJSON bodies on POST /import?project=<id> and
+// responds with the summary of the import. Requests must carry the shared
+// token, name a project of the allow-list and send at most cfg.maxBody bytes.
+// Imports are processed one at a time so that concurrent submissions for a
+// project do not overwrite each other.
+func importHandler(c *client.C, opts *options, cfg *serveConfig) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !cfg.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		lairPID := r.URL.Query().Get("project")
+		if lairPID == "" {
+			http.Error(w, "missing project parameter", http.StatusBadRequest)
+			return
+		}
+		if !cfg.projects[lairPID] {
+			http.Error(w, "project not allowed", http.StatusForbidden)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.maxBody)
+		defer r.Body.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		body, err := detectInput(r.Body, opts.format, opts.eventFilter())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+		s, err := run(c, opts, lairPID, body)
+		var tooLarge *http.MaxBytesError
+		if _, rerr := r.Body.Read(make([]byte, 1)); err != nil && errors.As(rerr, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			logf("Error: Import into project %s failed. Error %s", lairPID, err.Error())
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		status := http.StatusOK
+		if s.Refused != "" {
+			logf("Refused import into project %s: %s", lairPID, s.Refused)
+			status = http.StatusConflict
+		} else {
+			logf("Imported into project %s, %d hosts created, %d hosts updated", lairPID, s.HostsCreated, s.HostsUpdated)
+			s.Metrics.logStats()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(s)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// serviceTagNotePrefix starts the title of service tag notes. Lair services
+// have no tags, so each tag is recorded as a note of its own that can be
+// searched for.
+const serviceTagNotePrefix = "drone-bbot: service tag "
+
+// serviceTagSet are the tags recorded for a web service.
+type serviceTagSet struct {
+	ip     string
+	port   int
+	scheme string
+	tags   map[string]bool
+}
+
+// recordServiceTags remembers the tags for the web service rawURL was seen on
+// with -service-tags: http, tls for HTTPS, and bbot-module:<module> for the
+// module that reported the event.
+func (im *importer) recordServiceTags(entry map[string]interface{}, rawURL string) {
+	if !im.opts.serviceTags || rawURL == "" {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	tags := []string{"http"}
+	if u.Scheme == "https" {
+		tags = append(tags, "tls")
+	}
+	if module, _ := entry["module"].(string); module != "" {
+		tags = append(tags, "bbot-module:"+module)
+	}
+	port := urlPort(rawURL)
+	for _, ip := range im.webIPs(entry) {
+		key := fmt.Sprintf("%s:%d", ip, port)
+		if im.serviceTags[key] == nil {
+			im.serviceTags[key] = &serviceTagSet{ip: ip, port: port, scheme: u.Scheme, tags: make(map[string]bool)}
+		}
+		for _, tag := range tags {
+			im.serviceTags[key].tags[im.opts.namespaceTag(sanitizeText(tag))] = true
+		}
+	}
+}
+
+// applyServiceTags adds a note for each recorded tag to its service. Hosts
+// that are not in the project are skipped.
+func (im *importer) applyServiceTags() {
+	for _, set := range im.serviceTags {
+		tags := []string{}
+		for tag := range set.tags {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		im.updateHost(set.ip, func(host *lair.Host) bool {
+			service := ensureService(host, set.port, "tcp", set.scheme)
+			changed := false
+			for _, tag := range tags {
+				title := serviceTagNotePrefix + tag
+				if hasNote(service.Notes, title) {
+					continue
+				}
+				service.Notes = append(service.Notes, lair.Note{
+					Title:          title,
+					Content:        tag,
+					LastModifiedBy: lastModifiedBy,
+				})
+				changed = true
+			}
+			return changed
+		})
+	}
+}
+
+// serviceTags returns the tags recorded as notes on service.
+func serviceTags(service lair.Service) []string {
+	tags := []string{}
+	for _, note := range service.Notes {
+		if strings.HasPrefix(note.Title, serviceTagNotePrefix) {
+			tags = append(tags, strings.TrimPrefix(note.Title, serviceTagNotePrefix))
+		}
+	}
+	return tags
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// severities are ordered from least to most severe.
+var severities = []string{"info", "low", "medium", "high", "critical"}
+
+// severityRank returns the position of name in severities.
+func severityRank(name string) (int, bool) {
+	for i, s := range severities {
+		if s == strings.ToLower(name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// issueSeverity returns the severity of an issue with the given CVSS score.
+func issueSeverity(cvss float64) string {
+	switch {
+	case cvss >= 9:
+		return "critical"
+	case cvss >= 7:
+		return "high"
+	case cvss >= 4:
+		return "medium"
+	case cvss > 0:
+		return "low"
+	default:
+		return "info"
+	}
+}
+
+// filterIssues splits issues into those at or above the minimum severity rank
+// and those below it.
+func filterIssues(issues []lair.Issue, min int) ([]lair.Issue, []lair.Issue) {
+	kept := []lair.Issue{}
+	skipped := []lair.Issue{}
+	for _, issue := range issues {
+		rank, _ := severityRank(issueSeverity(issue.CVSS))
+		if rank >= min {
+			kept = append(kept, issue)
+		} else {
+			skipped = append(skipped, issue)
+		}
+	}
+	return kept, skipped
+}
+
+// writeIssues writes issues to path as JSON.
+func writeIssues(path string, issues []lair.Issue) error {
+	data, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
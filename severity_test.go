@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lair-framework/go-lair"
+)
+
+func TestFilterIssues(t *testing.T) {
+	im := &importer{opts: &options{findings: "issue"}, project: &lair.Project{}, issueIndex: make(map[string]int), metrics: newMetrics()}
+	im.addFinding(map[string]interface{}{"type": "FINDING", "host": "192.0.2.1", "module": "badsecrets"}, "Exposed admin panel", "")
+	issues := append(im.project.Issues,
+		newIssue("header", "Missing security header", 0, "", ""),
+		newIssue("medium", "Medium issue", 5, "", ""),
+		newIssue("critical", "Critical issue", 9.8, "", ""),
+	)
+	if len(issues) != 4 || issues[0].Rating != "low" {
+		t.Fatalf("FINDING issue = %+v, want a single issue rated low", im.project.Issues)
+	}
+	tests := []struct {
+		min  string
+		want []string
+	}{
+		{"info", []string{"Exposed admin panel", "Missing security header", "Medium issue", "Critical issue"}},
+		{"low", []string{"Exposed admin panel", "Medium issue", "Critical issue"}},
+		{"medium", []string{"Medium issue", "Critical issue"}},
+		{"critical", []string{"Critical issue"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.min, func(t *testing.T) {
+			rank, _ := severityRank(tt.min)
+			kept, skipped := filterIssues(issues, rank)
+			if len(kept)+len(skipped) != len(issues) || len(kept) != len(tt.want) {
+				t.Fatalf("filterIssues() kept %d and skipped %d, want %d kept", len(kept), len(skipped), len(tt.want))
+			}
+			for i, issue := range kept {
+				if issue.Title != tt.want[i] {
+					t.Errorf("kept[%d] = %s, want %s", i, issue.Title, tt.want[i])
+				}
+			}
+		})
+	}
+}
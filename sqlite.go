@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteEventTables are the names of the table bbot's sqlite output module
+// writes events to.
+var sqliteEventTables = []string{"event", "events"}
+
+// sqliteJSONColumns are the event columns bbot stores as JSON text.
+var sqliteJSONColumns = map[string]bool{
+	"data_json":      true,
+	"resolved_hosts": true,
+	"dns_children":   true,
+	"tags":           true,
+	"discovery_path": true,
+	"parent_chain":   true,
+}
+
+// eventFilter selects the events read from a bbot SQLite database by type and
+// by the module that produced them, so that -only and -modules are applied in
+// SQL rather than after decoding every event. A nil map selects everything.
+// SCAN events and the events of bbot's targets are always selected, as they
+// hold the scan metadata and scope.
+type eventFilter struct {
+	types   map[string]bool
+	modules map[string]bool
+}
+
+// eventFilter returns the filter of -only and -modules.
+func (o *options) eventFilter() eventFilter {
+	return eventFilter{types: o.onlyTypes, modules: o.onlyModules}
+}
+
+// where returns the WHERE clause of the filter and its arguments, or an empty
+// clause when everything is selected.
+func (f eventFilter) where() (string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+	for _, column := range []struct {
+		name   string
+		values map[string]bool
+	}{{"type", f.types}, {"module", f.modules}} {
+		if column.values == nil {
+			continue
+		}
+		values := make([]string, 0, len(column.values))
+		for value := range column.values {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			args = append(args, value)
+		}
+		conditions = append(conditions, column.name+" IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")+")")
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE type = 'SCAN' OR module = 'TARGET' OR (" + strings.Join(conditions, " AND ") + ")", args
+}
+
+// sqliteInput returns the events of the bbot SQLite database read by r that
+// match filter, with br the buffered reader detectInput peeked r with. The
+// database is opened read-only in place when r is a regular file. Otherwise,
+// such as for stdin, a pipe or an HTTP request, it is first copied to a
+// temporary file that is removed when the input is closed.
+func sqliteInput(r io.Reader, br *bufio.Reader, filter eventFilter) (*input, error) {
+	in := &input{format: "bbot SQLite database"}
+	path := ""
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			path = f.Name()
+		}
+	}
+	if path == "" {
+		tmp, err := os.CreateTemp("", "drone-bbot-*.sqlite")
+		if err != nil {
+			return nil, err
+		}
+		in.closers = append(in.closers, removeFile(tmp.Name()))
+		_, err = io.Copy(tmp, br)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			in.Close()
+			return nil, err
+		}
+		path = tmp.Name()
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", (&url.URL{Scheme: "file", Path: abs, RawQuery: "mode=ro"}).String())
+	if err != nil {
+		in.Close()
+		return nil, err
+	}
+	in.closers = append(in.closers, db)
+	in.Reader = convertInput(nil, func(_ io.Reader, emit func(map[string]interface{}) error) error {
+		return sqliteEvents(db, filter, emit)
+	})
+	return in, nil
+}
+
+// removeFile is a closer that removes the SQLite database at its path, with
+// the write-ahead log and shared memory files opening it may have created.
+type removeFile string
+
+func (path removeFile) Close() error {
+	for _, suffix := range []string{"-wal", "-shm"} {
+		os.Remove(string(path) + suffix)
+	}
+	return os.Remove(string(path))
+}
+
+// sqliteEvents emits the rows of the event table written by bbot's sqlite
+// output module that match filter as events, in the order they were
+// inserted. Columns holding JSON are decoded, and data_json replaces data for
+// events whose data is not a string.
+func sqliteEvents(db *sql.DB, filter eventFilter, emit func(map[string]interface{}) error) error {
+	table := ""
+	for _, name := range sqliteEventTables {
+		var found string
+		err := db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND lower(name) = ?", name).Scan(&found)
+		if err == nil {
+			table = found
+			break
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("invalid SQLite database: %s", err.Error())
+		}
+	}
+	if table == "" {
+		return fmt.Errorf("no %s table", strings.Join(sqliteEventTables, " or "))
+	}
+	where, args := filter.where()
+	rows, err := db.Query(`SELECT * FROM "`+strings.ReplaceAll(table, `"`, `""`)+`"`+where+" ORDER BY rowid", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		event := map[string]interface{}{}
+		for i, column := range columns {
+			column = strings.ToLower(column)
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			switch v := values[i].(type) {
+			case nil:
+			case int64:
+				event[column] = float64(v)
+			case string:
+				var decoded interface{}
+				if sqliteJSONColumns[column] && json.Unmarshal([]byte(v), &decoded) == nil {
+					event[column] = decoded
+				} else {
+					event[column] = v
+				}
+			case time.Time:
+				event[column] = v.UTC().Format(time.RFC3339Nano)
+			default:
+				event[column] = v
+			}
+		}
+		if data, found := event["data_json"]; found {
+			if data != nil {
+				event["data"] = data
+			}
+			delete(event, "data_json")
+		}
+		if err := emit(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
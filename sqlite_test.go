@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSQLiteEvents creates a database in the layout of bbot's sqlite output
+// module holding the given events, each a type, module and data_json, which
+// is NULL when empty.
+func writeSQLiteEvents(t *testing.T, events [][3]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "output.sqlite")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	statements := []string{
+		"PRAGMA journal_mode=WAL",
+		`CREATE TABLE event (uuid VARCHAR NOT NULL, id VARCHAR NOT NULL, type VARCHAR NOT NULL, data VARCHAR, data_json JSON,
+			host VARCHAR, port INTEGER, resolved_hosts JSON, tags JSON, module VARCHAR, PRIMARY KEY (uuid))`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, event := range events {
+		var data interface{}
+		if event[2] != "" {
+			data = event[2]
+		}
+		_, err := db.Exec(`INSERT INTO event (uuid, id, type, data_json, host, port, resolved_hosts, tags, module)
+			VALUES (?, ?, ?, ?, 'www.example.com', 443, '["192.0.2.1"]', '["in-scope"]', ?)`,
+			// uuids sort in the reverse of insertion order, events are
+			// expected in insertion order.
+			string(rune('z'-i)), event[0]+":"+string(rune('a'+i)), event[0], data, event[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestSQLiteEvents(t *testing.T) {
+	path := writeSQLiteEvents(t, [][3]string{
+		{"SCAN", "SCAN", `{"name":"test_scan"}`},
+		{"DNS_NAME", "TARGET", ""},
+		{"OPEN_TCP_PORT", "portscan", ""},
+		{"DNS_NAME", "crt", ""},
+		{"FINDING", "nuclei", `{"description":"exposed panel","url":"https://www.example.com/"}`},
+	})
+	tests := []struct {
+		name   string
+		filter eventFilter
+		want   []string
+	}{
+		{"all events", eventFilter{}, []string{"SCAN", "DNS_NAME", "OPEN_TCP_PORT", "DNS_NAME", "FINDING"}},
+		{"types", eventFilter{types: map[string]bool{"FINDING": true}}, []string{"SCAN", "DNS_NAME", "FINDING"}},
+		{"modules", eventFilter{modules: map[string]bool{"portscan": true}}, []string{"SCAN", "DNS_NAME", "OPEN_TCP_PORT"}},
+		{
+			"types and modules",
+			eventFilter{types: map[string]bool{"DNS_NAME": true}, modules: map[string]bool{"crt": true, "nuclei": true}},
+			[]string{"SCAN", "DNS_NAME", "DNS_NAME"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := openInput(path, "", tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+			if in.format != "bbot SQLite database" {
+				t.Errorf("format = %q, want bbot SQLite database", in.format)
+			}
+			got := []string{}
+			scanner := newEventScanner(in)
+			for scanner.Scan() {
+				var entry map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, entry["type"].(string))
+				if entry["port"] != 443.0 {
+					t.Errorf("port = %v, want 443", entry["port"])
+				}
+				if !reflect.DeepEqual(entry["resolved_hosts"], []interface{}{"192.0.2.1"}) {
+					t.Errorf("resolved_hosts = %v, want [192.0.2.1]", entry["resolved_hosts"])
+				}
+				if _, found := entry["data_json"]; found {
+					t.Errorf("data_json was not replaced by data")
+				}
+				if entry["type"] == "FINDING" {
+					data, _ := entry["data"].(map[string]interface{})
+					if data["description"] != "exposed panel" {
+						t.Errorf("data = %v, want the decoded data_json", entry["data"])
+					}
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("event types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.ndjson")
+	if err := os.WriteFile(path, []byte(`{"type":"DNS_NAME","data":"www.example.com"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if in, err := openInput(path, "sqlite", eventFilter{}); err == nil {
+		in.Close()
+		t.Errorf("openInput(%s) with -format sqlite succeeded for NDJSON", path)
+	}
+	empty := writeSQLiteEvents(t, nil)
+	in, err := openInput(empty, "", eventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	scanner := newEventScanner(in)
+	if scanner.Scan() {
+		t.Errorf("read %q from a database without events", scanner.Text())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lair-framework/api-server/client"
+	"github.com/lair-framework/go-lair"
+)
+
+const (
+	// ephemeralTagPrefix marks tags that expire after -tag-ttl.
+	ephemeralTagPrefix = "ephemeral:"
+	// tagExpiryNotePrefix starts the title of the note recording when an
+	// ephemeral tag expires. The title ends with the expiry, so every run that
+	// applies the tag records a note and the latest expiry applies.
+	tagExpiryNotePrefix = "drone-bbot: tag expiry "
+)
+
+// isEphemeralTag reports whether tag, with or without the tag namespace,
+// expires after -tag-ttl.
+func (o *options) isEphemeralTag(tag string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(tag, o.tagNamespace), ephemeralTagPrefix)
+}
+
+// addTagExpiry records a note on host for each ephemeral tag applied by this
+// run, expiring ttl after now.
+func (o *options) addTagExpiry(host *lair.Host, now time.Time) bool {
+	expires := now.Add(o.tagTTL).UTC().Format(time.RFC3339)
+	changed := false
+	for _, tag := range o.hostTags {
+		if !o.isEphemeralTag(tag) || len(missing([]string{tag}, host.Tags)) > 0 {
+			continue
+		}
+		title := tagExpiryNotePrefix + tag + " " + expires
+		if hasNote(host.Notes, title) {
+			continue
+		}
+		host.Notes = append(host.Notes, lair.Note{
+			Title:          title,
+			Content:        fmt.Sprintf("The tag %s was applied by drone-bbot and expires at %s. Run drone-bbot prune-tags to list expired tags.", tag, expires),
+			LastModifiedBy: lastModifiedBy,
+		})
+		changed = true
+	}
+	return changed
+}
+
+// applyTagExpiry records the expiry of ephemeral tags with -tag-ttl on every
+// host this import creates or updates.
+func (im *importer) applyTagExpiry(now time.Time) {
+	if im.opts.tagTTL <= 0 {
+		return
+	}
+	for i := range im.project.Hosts {
+		im.opts.addTagExpiry(&im.project.Hosts[i], now)
+	}
+	for ip := range im.updated {
+		im.updateHost(ip, func(host *lair.Host) bool {
+			return im.opts.addTagExpiry(host, now)
+		})
+	}
+}
+
+// tagExpiries returns the latest expiry recorded for each tag of host.
+func tagExpiries(host lair.Host) map[string]time.Time {
+	expiries := make(map[string]time.Time)
+	for _, note := range host.Notes {
+		if !strings.HasPrefix(note.Title, tagExpiryNotePrefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(note.Title, tagExpiryNotePrefix)
+		i := strings.LastIndex(rest, " ")
+		if i < 0 {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, rest[i+1:])
+		if err != nil {
+			continue
+		}
+		if tag := rest[:i]; expires.After(expiries[tag]) {
+			expiries[tag] = expires
+		}
+	}
+	return expiries
+}
+
+// expiredTags returns the IPs of the hosts in project that still carry each
+// ephemeral tag whose latest expiry is before now.
+func expiredTags(project lair.Project, now time.Time) map[string][]string {
+	expired := make(map[string][]string)
+	for _, host := range project.Hosts {
+		expiries := tagExpiries(host)
+		for _, tag := range host.Tags {
+			if expires, found := expiries[tag]; found && expires.Before(now) {
+				expired[tag] = append(expired[tag], host.IPv4)
+			}
+		}
+	}
+	for tag := range expired {
+		sortIPs(expired[tag])
+	}
+	return expired
+}
+
+// pruneTags lists the expired ephemeral tags of a project. Lair's import can
+// only add tags, so the tags are returned, one line per tag with its hosts,
+// for an analyst to remove.
+func pruneTags(c *client.C, lairPID string, now time.Time) ([]string, error) {
+	project, err := c.ExportProject(lairPID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to export project: %s", err.Error())
+	}
+	expired := expiredTags(project, now)
+	tags := []string{}
+	for tag := range expired {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	lines := []string{}
+	for _, tag := range tags {
+		lines = append(lines, fmt.Sprintf("%s: %s", tag, strings.Join(expired[tag], ", ")))
+	}
+	return lines, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+)
+
+// splitTags splits a comma separated list of tags, trimming whitespace and
+// dropping empty entries.
+func splitTags(list string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(sanitizeText(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// readTagsFile reads tags from path, one or more comma separated tags per
+// line. Lines starting with # are ignored.
+func readTagsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tags := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		tags = append(tags, splitTags(line)...)
+	}
+	return tags, scanner.Err()
+}
+
+// namespaceTag prefixes a tag written by the drone with -tag-namespace so that
+// drone tags can be told apart from tags applied by analysts, and sanitizes
+// it, slugifying it with -slug-tags.
+func (o *options) namespaceTag(tag string) string {
+	if o.tagNamespace != "" && !strings.HasPrefix(tag, o.tagNamespace) {
+		tag = o.tagNamespace + tag
+	}
+	return sanitizeTag(tag, o.slugTags)
+}
+
+// lowConfidenceTags returns the tags of hosts created by -low-confidence.
+func (o *options) lowConfidenceTags() []string {
+	return append(append([]string{}, o.hostTags...), o.namespaceTag("low-confidence"))
+}
+
+// isLegacyTag reports whether tag is a drone tag written before
+// -tag-namespace was introduced.
+func (o *options) isLegacyTag(tag string) bool {
+	if o.tagNamespace == "" || strings.HasPrefix(tag, o.tagNamespace) {
+		return false
+	}
+	if strings.HasPrefix(tag, "retired:") {
+		return true
+	}
+	for _, t := range o.rawTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateTags adds the namespaced form of every legacy drone tag on the
+// existing hosts. Lair's import can not remove tags, so the legacy tags are
+// returned for an analyst to remove.
+func (im *importer) migrateTags() []string {
+	legacy := make(map[string]bool)
+	for ip, host := range im.existingIPs {
+		changed := false
+		for _, tag := range host.Tags {
+			if !im.opts.isLegacyTag(tag) {
+				continue
+			}
+			legacy[tag] = true
+			var added bool
+			host.Tags, added = appendUnique(host.Tags, im.opts.namespaceTag(tag))
+			changed = changed || added
+		}
+		if changed {
+			host.LastModifiedBy = lastModifiedBy
+			im.existingIPs[ip] = host
+			im.updated[ip] = true
+		}
+	}
+	tags := []string{}
+	for tag := range legacy {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// takeoverCVSS is the lowest CVSS score of a subdomain takeover issue, so that
+// takeover candidates are rated high even when bbot reports them as a FINDING
+// or with a lower severity.
+const takeoverCVSS = 7.5
+
+var (
+	takeoverSubdomainPattern = regexp.MustCompile(`(?i)subdomain:\s*\[([^\]]+)\]`)
+	takeoverTriggerPattern   = regexp.MustCompile(`(?i)trigger:\s*\[([^\]]+)\]`)
+)
+
+// takeover is a subdomain takeover candidate reported by bbot.
+type takeover struct {
+	entry       map[string]interface{}
+	subdomain   string
+	description string
+	cvss        float64
+}
+
+// isTakeover reports whether a FINDING or VULNERABILITY event reports a
+// subdomain takeover candidate, such as those of bbot's baddns modules.
+func isTakeover(entry map[string]interface{}, description string) bool {
+	if strings.Contains(strings.ToLower(description), "takeover") {
+		return true
+	}
+	tags, _ := entry["tags"].([]interface{})
+	for _, t := range tags {
+		if tag, _ := t.(string); strings.Contains(strings.ToLower(tag), "takeover") {
+			return true
+		}
+	}
+	return false
+}
+
+// recordTakeover records the subdomain takeover candidate of a FINDING or
+// VULNERABILITY event. The issue is created once every event has been read,
+// when the CNAME chain of the subdomain is known.
+func (im *importer) recordTakeover(entry map[string]interface{}, description string, cvss float64) {
+	data, _ := entry["data"].(map[string]interface{})
+	subdomain, _ := data["host"].(string)
+	if m := takeoverSubdomainPattern.FindStringSubmatch(description); m != nil {
+		subdomain = m[1]
+	}
+	if subdomain == "" {
+		subdomain, _ = entry["host"].(string)
+	}
+	if host, _, err := net.SplitHostPort(subdomain); err == nil {
+		subdomain = host
+	}
+	subdomain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(subdomain), "."))
+	if subdomain == "" {
+		return
+	}
+	if cvss < takeoverCVSS {
+		cvss = takeoverCVSS
+	}
+	im.takeovers = append(im.takeovers, takeover{entry: entry, subdomain: subdomain, description: description, cvss: cvss})
+}
+
+// recordCNAMEs remembers the CNAME records in an event's dns_children, for the
+// CNAME chains of subdomain takeover issues.
+func (im *importer) recordCNAMEs(entry map[string]interface{}) {
+	host, _ := entry["host"].(string)
+	if host == "" {
+		return
+	}
+	for rtype, raw := range dnsChildren(entry) {
+		if !strings.EqualFold(rtype, "CNAME") {
+			continue
+		}
+		for _, value := range childValues(raw) {
+			if target, ok := value.(string); ok && target != "" {
+				im.recordCNAME(host, target)
+			}
+		}
+	}
+}
+
+// recordCNAME remembers that name is a CNAME for target.
+func (im *importer) recordCNAME(name, target string) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	im.cnames[name] = strings.ToLower(strings.TrimSuffix(target, "."))
+}
+
+// cnameChain returns the CNAME chain starting at name, such as
+// "a.example.com -> a.azurewebsites.net", or an empty string when name has no
+// known CNAME record.
+func (im *importer) cnameChain(name string) string {
+	chain := []string{name}
+	seen := map[string]bool{name: true}
+	for target := im.cnames[name]; target != "" && !seen[target] && len(chain) <= maxDNSChildDepth; target = im.cnames[target] {
+		chain = append(chain, target)
+		seen[target] = true
+	}
+	if len(chain) == 1 {
+		return ""
+	}
+	return strings.Join(chain, " -> ")
+}
+
+// applyTakeovers creates a high rated issue for each subdomain takeover
+// candidate, with the CNAME chain of the subdomain and the dangling target
+// bbot found as evidence. The issue is attached to the hosts the event
+// resolved to and the hosts in Lair that have the subdomain as a hostname.
+func (im *importer) applyTakeovers() {
+	for _, t := range im.takeovers {
+		module, _ := t.entry["module"].(string)
+		issue := newIssue("takeover-"+t.subdomain, sanitizeText("Subdomain takeover candidate: "+t.subdomain), t.cvss, sanitizeText(t.description),
+			"Remove the DNS record of the subdomain, or claim the resource it points to, before someone else does.")
+		if module != "" {
+			issue.IdentifiedBy = append(issue.IdentifiedBy, lair.IdentifiedBy{Tool: "bbot " + module})
+		}
+		evidence := []string{"Subdomain: " + t.subdomain}
+		if chain := im.cnameChain(t.subdomain); chain != "" {
+			evidence = append(evidence, "CNAME chain: "+chain)
+		}
+		if m := takeoverTriggerPattern.FindStringSubmatch(t.description); m != nil {
+			evidence = append(evidence, "Dangling target: "+strings.TrimSpace(m[1]))
+		}
+		if history := im.dnsHistory[t.subdomain]; history != "" {
+			evidence = append(evidence, "Passive DNS: "+history)
+		}
+		if chain := im.provenance(t.entry); chain != "" {
+			evidence = append(evidence, chain)
+		}
+		issue.Evidence = strings.Join(evidence, "\n")
+		issue.Hosts = issueHosts(t.entry, 0)
+		for _, ip := range im.names[t.subdomain] {
+			known := false
+			for _, h := range issue.Hosts {
+				known = known || h.IPv4 == ip
+			}
+			if !known {
+				issue.Hosts = append(issue.Hosts, lair.IssueHost{IPv4: ip, Port: 0, Protocol: "tcp"})
+			}
+		}
+		im.addIssue(issue)
+	}
+}
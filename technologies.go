@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// technologyNotePrefix starts the title of technology notes, one per
+// technology so that each survives Lair keeping only the first note with a
+// given title.
+const technologyNotePrefix = "drone-bbot: technology "
+
+// technologySet are the technologies identified on a service.
+type technologySet struct {
+	ip           string
+	port         int
+	scheme       string
+	technologies map[string]string
+}
+
+// technologyService returns the port and scheme of the service a TECHNOLOGY
+// event was identified on, from its URL or otherwise its host:port.
+func technologyService(data map[string]interface{}) (int, string) {
+	if rawURL, _ := data["url"].(string); rawURL != "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			return urlPort(rawURL), u.Scheme
+		}
+	}
+	host, _ := data["host"].(string)
+	if _, p, err := net.SplitHostPort(host); err == nil {
+		port, _ := strconv.Atoi(p)
+		return port, ""
+	}
+	return 0, ""
+}
+
+// recordTechnology remembers a technology reported by bbot's wappalyzer or
+// fingerprint modules for the service it was identified on.
+func (im *importer) recordTechnology(entry map[string]interface{}, technology string) {
+	technology = sanitizeText(strings.TrimSpace(technology))
+	data, _ := entry["data"].(map[string]interface{})
+	port, scheme := technologyService(data)
+	if technology == "" || port == 0 {
+		return
+	}
+	module, _ := entry["module"].(string)
+	for _, ip := range im.webIPs(entry) {
+		key := fmt.Sprintf("%s:%d", ip, port)
+		if im.technologies[key] == nil {
+			im.technologies[key] = &technologySet{ip: ip, port: port, scheme: scheme, technologies: make(map[string]string)}
+		}
+		if _, found := im.technologies[key].technologies[technology]; !found {
+			im.technologies[key].technologies[technology] = module
+		}
+	}
+}
+
+// applyTechnologies adds a note for each recorded technology to its service,
+// and sets the product of services that have none to the technologies seen.
+// Lair only replaces an empty or unknown product, so a fingerprint from
+// another tool is kept. Hosts that are not in the project are skipped.
+func (im *importer) applyTechnologies() {
+	for _, set := range im.technologies {
+		technologies := []string{}
+		for technology := range set.technologies {
+			technologies = append(technologies, technology)
+		}
+		sort.Strings(technologies)
+		im.updateHost(set.ip, func(host *lair.Host) bool {
+			service := ensureService(host, set.port, "tcp", set.scheme)
+			changed := false
+			if service.Product == "" || strings.EqualFold(service.Product, "unknown") {
+				service.Product = strings.Join(technologies, ", ")
+				changed = true
+			}
+			for _, technology := range technologies {
+				title := technologyNotePrefix + technology
+				if hasNote(service.Notes, title) {
+					continue
+				}
+				content := technology
+				if module := set.technologies[technology]; module != "" {
+					content += "\n\nIdentified by bbot module " + module
+				}
+				service.Notes = append(service.Notes, lair.Note{
+					Title:          title,
+					Content:        content,
+					LastModifiedBy: lastModifiedBy,
+				})
+				changed = true
+			}
+			return changed
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// ANSI escape sequences used to colorize terminal output.
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// useColor reports whether log output is colorized. main sets it from
+// colorEnabled for the log output, so piped and redirected output stays plain.
+var useColor bool
+
+// colorEnabled reports whether output written to f should be colorized: f is
+// a terminal, NO_COLOR is not set and TERM is not dumb.
+func colorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in color when output is colorized.
+func paint(color, s string) string {
+	if !useColor || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// messageColor returns the color of a log message from the prefix of its
+// English format string, so that translated messages are colored the same.
+func messageColor(format string) string {
+	switch {
+	case strings.HasPrefix(format, "Fatal:"), strings.HasPrefix(format, "Error:"):
+		return colorRed
+	case strings.HasPrefix(format, "Warning:"), strings.HasPrefix(format, "Partial:"), strings.HasPrefix(format, "Refus"):
+		return colorYellow
+	case strings.HasPrefix(format, "Success:"):
+		return colorGreen
+	}
+	return ""
+}
+
+// logTable logs the lines of a table, with the header in the first line in
+// bold.
+func logTable(lines []string) {
+	for i, line := range lines {
+		if i == 0 {
+			line = paint(colorBold, line)
+		}
+		log.Println(line)
+	}
+}
+
+// logDiff logs the lines of a comparison report, with the summary in the first
+// line in bold and each difference colored by its marker.
+func logDiff(lines []string) {
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			line = paint(colorBold, line)
+		case strings.HasPrefix(line, "+ "):
+			line = paint(colorGreen, line)
+		case strings.HasPrefix(line, "- "):
+			line = paint(colorRed, line)
+		case strings.HasPrefix(line, "~ "):
+			line = paint(colorYellow, line)
+		case strings.HasPrefix(line, "> "):
+			line = paint(colorCyan, line)
+		}
+		log.Println(line)
+	}
+}
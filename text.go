@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sanitizeText makes s safe to store in Lair. Invalid UTF-8 sequences are
+// replaced with U+FFFD and control characters other than newline and tab are
+// removed. Valid multi-byte text such as IDN hostnames or non-ASCII page
+// titles is returned unchanged.
+func sanitizeText(s string) string {
+	if utf8.ValidString(s) && strings.IndexFunc(s, isUnsafeControl) == -1 {
+		return s
+	}
+	s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	return strings.Map(func(r rune) rune {
+		if isUnsafeControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func isUnsafeControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\n' && r != '\t'
+}
+
+// maxTagLength is the longest tag written to Lair, in characters. Longer tags
+// break the layout of some Lair views.
+const maxTagLength = 128
+
+// sanitizeTag makes tag safe to display in Lair. Control and invisible
+// formatting characters are removed, runs of whitespace collapse to a single
+// space and the tag is cut to maxTagLength characters. With slug, the tag is
+// lowercased and every run of characters other than letters, digits and
+// ":._-" becomes a single dash.
+func sanitizeTag(tag string, slug bool) string {
+	tag = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(tag, ""))
+	tag = strings.Join(strings.Fields(tag), " ")
+	if slug {
+		var b strings.Builder
+		dash := false
+		for _, r := range strings.ToLower(tag) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(":._-", r) {
+				b.WriteRune(r)
+				dash = false
+			} else if !dash {
+				b.WriteRune('-')
+				dash = true
+			}
+		}
+		tag = strings.Trim(b.String(), "-")
+	}
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		tag = strings.TrimRight(string([]rune(tag)[:maxTagLength]), " -")
+	}
+	return tag
+}
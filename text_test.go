@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ascii", "www.example.com", "www.example.com"},
+		{"idn hostname", "bücher.example.de", "bücher.example.de"},
+		{"punycode hostname", "xn--bcher-kva.example.de", "xn--bcher-kva.example.de"},
+		{"cjk hostname", "例え.テスト", "例え.テスト"},
+		{"non-ascii title", "Anmeldung – Übersicht", "Anmeldung – Übersicht"},
+		{"emoji title", "Status 🚀 OK", "Status 🚀 OK"},
+		{"newline and tab kept", "line one\n\tline two", "line one\n\tline two"},
+		{"control characters removed", "bell\a null\x00 escape\x1b[0m", "bell null escape[0m"},
+		{"invalid byte replaced", "bad\xffname.example.com", "bad�name.example.com"},
+		{"truncated sequence replaced", "caf\xc3", "caf�"},
+		{"invalid run replaced once", "a\xff\xfeb", "a�b"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeText(tt.in); got != tt.want {
+				t.Errorf("sanitizeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNSNameHostnames(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  map[string][]string
+	}{
+		{
+			name:  "idn hostname",
+			event: `{"type":"DNS_NAME","host":"bücher.example.de","resolved_hosts":["192.0.2.1"]}`,
+			want:  map[string][]string{"192.0.2.1": {"bücher.example.de"}},
+		},
+		{
+			name:  "escaped idn hostname",
+			event: `{"type":"DNS_NAME","host":"m\u00fcnchen.example.de","resolved_hosts":["192.0.2.2"]}`,
+			want:  map[string][]string{"192.0.2.2": {"münchen.example.de"}},
+		},
+		{
+			name:  "punycode hostname",
+			event: `{"type":"DNS_NAME","host":"xn--mnchen-3ya.example.de","resolved_hosts":["192.0.2.3"]}`,
+			want:  map[string][]string{"192.0.2.3": {"xn--mnchen-3ya.example.de"}},
+		},
+		{
+			name:  "control character removed",
+			event: `{"type":"DNS_NAME","host":"www\u0007.example.com","resolved_hosts":["192.0.2.4"]}`,
+			want:  map[string][]string{"192.0.2.4": {"www.example.com"}},
+		},
+		{
+			name:  "idn cname target",
+			event: `{"type":"DNS_NAME","host":"shop.example.com","resolved_hosts":["192.0.2.5"],"dns_children":{"CNAME":["bücher.example.de."]}}`,
+			want:  map[string][]string{"192.0.2.5": {"shop.example.com", "bücher.example.de"}},
+		},
+		{
+			name:  "missing host",
+			event: `{"type":"DNS_NAME","resolved_hosts":["192.0.2.6"]}`,
+			want:  map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.event), &entry); err != nil {
+				t.Fatal(err)
+			}
+			names := make(map[string][]string)
+			addDNSNames(names, entry, 0)
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("addDNSNames() = %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPResponseTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  string
+	}{
+		{
+			name:  "ascii title",
+			event: `{"type":"HTTP_RESPONSE","data":{"url":"https://www.example.com/","status_code":200,"title":"Welcome"}}`,
+			want:  "URL: https://www.example.com/\nStatus: 200\nTitle: Welcome\n",
+		},
+		{
+			name:  "german title",
+			event: `{"type":"HTTP_RESPONSE","data":{"url":"https://www.example.de/","status_code":200,"title":"Anmeldung – Übersicht"}}`,
+			want:  "URL: https://www.example.de/\nStatus: 200\nTitle: Anmeldung – Übersicht\n",
+		},
+		{
+			name:  "escaped japanese title",
+			event: `{"type":"HTTP_RESPONSE","data":{"url":"https://www.example.jp/","status_code":200,"title":"\u30ed\u30b0\u30a4\u30f3"}}`,
+			want:  "URL: https://www.example.jp/\nStatus: 200\nTitle: ログイン\n",
+		},
+		{
+			name:  "idn url",
+			event: `{"type":"HTTP_RESPONSE","data":{"url":"https://bücher.example.de/","status_code":301,"title":"Bücher"}}`,
+			want:  "URL: https://bücher.example.de/\nStatus: 301\nTitle: Bücher\n",
+		},
+		{
+			name:  "control characters in title",
+			event: `{"type":"HTTP_RESPONSE","data":{"url":"https://www.example.com/","status_code":200,"title":"\u001b[1mAdmin\u0000"}}`,
+			want:  "URL: https://www.example.com/\nStatus: 200\nTitle: [1mAdmin\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.event), &entry); err != nil {
+				t.Fatal(err)
+			}
+			im := &importer{httpBanners: make(map[string]*httpBanner)}
+			data := entry["data"].(map[string]interface{})
+			rawURL := data["url"].(string)
+			entry["resolved_hosts"] = []interface{}{"192.0.2.1"}
+			im.recordHTTPBanner(entry, data, rawURL)
+			if len(im.httpBanners) != 1 {
+				t.Fatalf("recorded %d banners, want 1", len(im.httpBanners))
+			}
+			for _, b := range im.httpBanners {
+				if got := b.content(); got != tt.want {
+					t.Errorf("content() = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidUTF8Title(t *testing.T) {
+	b := &httpBanner{url: "https://www.example.com/", title: "caf\xe9 \xff"}
+	want := "URL: https://www.example.com/\nTitle: caf� �\n"
+	if got := b.content(); got != want {
+		t.Errorf("content() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lair-framework/go-lair"
+)
+
+const defaultTicketTemplate = `{{.Issue.Title}} was identified by drone-bbot in Lair project {{.Project}}.
+
+Severity: {{.Severity}} (CVSS {{.Issue.CVSS}})
+
+{{.Issue.Description}}
+
+Affected hosts:
+{{range .Issue.Hosts}}  {{.IPv4}}:{{.Port}}/{{.Protocol}}
+{{end}}
+Evidence:
+{{.Issue.Evidence}}
+`
+
+// ticketData is passed to the ticket body template. Severity is the severity
+// of the issue as -min-severity rates it, which unlike Lair's rating includes
+// critical.
+type ticketData struct {
+	Project  string
+	Issue    lair.Issue
+	Severity string
+}
+
+// ticketer opens tickets in JIRA or ServiceNow for high and critical issues.
+// Credentials are read from TICKET_USERNAME and TICKET_PASSWORD.
+type ticketer struct {
+	system   string
+	baseURL  string
+	queue    string
+	template *template.Template
+	client   *http.Client
+}
+
+// newTicketer validates the ticket options. templatePath may be empty to use
+// the default body template.
+func newTicketer(system, baseURL, queue, templatePath string) (*ticketer, error) {
+	if system != "jira" && system != "servicenow" {
+		return nil, fmt.Errorf("unsupported ticket system %q, expected jira or servicenow", system)
+	}
+	if baseURL == "" || queue == "" {
+		return nil, fmt.Errorf("-ticket-url and -ticket-queue are required")
+	}
+	text := defaultTicketTemplate
+	if templatePath != "" {
+		data, err := os.ReadFile(templatePath)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("ticket").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &ticketer{
+		system:   system,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		queue:    queue,
+		template: tmpl,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// newHighIssues returns the issues rated high or critical whose plugin IDs are
+// not already in the existing project.
+func newHighIssues(existing, imported []lair.Issue) []lair.Issue {
+	known := make(map[string]bool)
+	for _, issue := range existing {
+		for _, p := range issue.PluginIDs {
+			known[p.Tool+"/"+p.ID] = true
+		}
+	}
+	issues := []lair.Issue{}
+	for _, issue := range imported {
+		if issue.CVSS < 7 || len(issue.PluginIDs) == 0 {
+			continue
+		}
+		if !known[issue.PluginIDs[0].Tool+"/"+issue.PluginIDs[0].ID] {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// open creates a ticket for issue, which was imported into project.
+func (t *ticketer) open(project string, issue lair.Issue) error {
+	severity := issueSeverity(issue.CVSS)
+	var body bytes.Buffer
+	if err := t.template.Execute(&body, ticketData{Project: project, Issue: issue, Severity: severity}); err != nil {
+		return err
+	}
+	summary := fmt.Sprintf("[%s] %s", strings.ToUpper(severity), issue.Title)
+
+	var endpoint string
+	var payload interface{}
+	switch t.system {
+	case "jira":
+		endpoint = t.baseURL + "/rest/api/2/issue"
+		payload = map[string]interface{}{
+			"fields": map[string]interface{}{
+				"project":     map[string]string{"key": t.queue},
+				"summary":     summary,
+				"description": body.String(),
+				"issuetype":   map[string]string{"name": "Task"},
+			},
+		}
+	case "servicenow":
+		urgency := "2"
+		if issue.CVSS >= 9 {
+			urgency = "1"
+		}
+		endpoint = t.baseURL + "/api/now/table/incident"
+		payload = map[string]string{
+			"short_description": summary,
+			"description":       body.String(),
+			"assignment_group":  t.queue,
+			"urgency":           urgency,
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(os.Getenv("TICKET_USERNAME"), os.Getenv("TICKET_PASSWORD"))
+	res, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", t.system, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lair-framework/go-lair"
+)
+
+func TestTicketSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		cvss        float64
+		wantSummary string
+		wantBody    string
+	}{
+		{"high", 7.5, "[HIGH] SQL injection", "Severity: high (CVSS 7.5)"},
+		{"critical", 9.8, "[CRITICAL] SQL injection", "Severity: critical (CVSS 9.8)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Error(err)
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+			tk, err := newTicketer("jira", server.URL, "SEC", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tk.open("p1", newIssue("sqli", "SQL injection", tt.cvss, "", "")); err != nil {
+				t.Fatal(err)
+			}
+			if summary := got["fields"]["summary"]; summary != tt.wantSummary {
+				t.Errorf("summary = %v, want %s", summary, tt.wantSummary)
+			}
+			if body, _ := got["fields"]["description"].(string); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("description = %q, want it to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewHighIssues(t *testing.T) {
+	existing := []lair.Issue{newIssue("known", "Known issue", 8, "", "")}
+	imported := []lair.Issue{
+		newIssue("known", "Known issue", 8, "", ""),
+		newIssue("new", "New issue", 9.1, "", ""),
+		newIssue("medium", "Medium issue", 6.9, "", ""),
+	}
+	got := newHighIssues(existing, imported)
+	if len(got) != 1 || got[0].Title != "New issue" {
+		t.Errorf("newHighIssues() = %v, want only New issue", got)
+	}
+}
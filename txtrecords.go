@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// txtRule reports TXT records matching pattern as an informational issue.
+type txtRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// defaultTXTRules match TXT record content that exposes credentials, internal
+// infrastructure or the SaaS products an organization uses.
+var defaultTXTRules = []txtRule{
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[0-9A-Za-z\-]{10,}`)},
+	{"Private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"Credential", regexp.MustCompile(`(?i)\b(api[_\-]?key|secret|token|passw(or)?d)\s*[=:]\s*\S{8,}`)},
+	{"Internal hostname", regexp.MustCompile(`(?i)\b[a-z0-9\-]+(\.[a-z0-9\-]+)*\.(internal|corp|local|lan|intranet|intra)\b`)},
+	{"SaaS verification token", regexp.MustCompile(`(?i)^([a-z0-9\-]+-(site|domain)-verification|ms|docusign|adobe-idp-site-verification|atlassian-domain-verification|stripe-verification|zoom_verify_[a-z0-9]+)[=:]`)},
+}
+
+// loadTXTRules reads custom rules from path, one per line as "<name>: <regex>".
+// Blank lines and lines starting with # are ignored.
+func loadTXTRules(path string) ([]txtRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules := []txtRule{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ": ", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("line %d: expected <name>: <regex>", n)
+		}
+		pattern, err := regexp.Compile(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err.Error())
+		}
+		rules = append(rules, txtRule{name: strings.TrimSpace(parts[0]), pattern: pattern})
+	}
+	return rules, scanner.Err()
+}
+
+// txtPluginID returns the plugin ID of the issue for a rule, so that each rule
+// is a separate issue in Lair.
+func txtPluginID(name string) string {
+	return "txt-" + strings.Trim(cpeUnsafePattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// checkTXTRecords records an issue for each rule a TXT record of name
+// matches, with the record as evidence.
+func (im *importer) checkTXTRecords(entry map[string]interface{}, name string, records []string) {
+	for _, record := range records {
+		record = strings.Trim(record, `"`)
+		for _, rule := range im.opts.txtRules {
+			if !rule.pattern.MatchString(record) {
+				continue
+			}
+			issue := newIssue(
+				txtPluginID(rule.name),
+				"Sensitive Data in DNS TXT Record: "+rule.name,
+				0,
+				"A public DNS TXT record contains data matching the "+rule.name+" rule. TXT records can be read by anyone and "+
+					"may disclose credentials, internal infrastructure or the third party services an organization uses.",
+				"Remove the record if it is no longer required, and rotate any credentials it exposed.",
+			)
+			issue.Hosts = []lair.IssueHost{}
+			for _, ip := range eventIPs(entry) {
+				issue.Hosts = append(issue.Hosts, lair.IssueHost{IPv4: ip, Port: 0, Protocol: "tcp"})
+			}
+			issue.Evidence = fmt.Sprintf("%s TXT %q", name, record)
+			im.addIssue(issue)
+		}
+	}
+}
+
+// txtChildren returns the TXT records in an event's dns_children.
+func txtChildren(entry map[string]interface{}) []string {
+	records := []string{}
+	for _, value := range childValues(dnsChildren(entry)["TXT"]) {
+		if record, ok := value.(string); ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// handleRawDNSRecord records the CNAME records bbot reports as RAW_DNS_RECORD
+// events for takeover evidence and checks TXT records with -txt-secrets.
+func (im *importer) handleRawDNSRecord(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	rtype, _ := data["type"].(string)
+	host, _ := data["host"].(string)
+	answer, _ := data["answer"].(string)
+	if host == "" || answer == "" {
+		return
+	}
+	switch {
+	case strings.EqualFold(rtype, "CNAME"):
+		im.recordCNAME(host, answer)
+	case strings.EqualFold(rtype, "TXT") && len(im.opts.txtRules) > 0:
+		im.checkTXTRecords(entry, host, []string{answer})
+	}
+}
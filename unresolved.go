@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// unresolvedModes are the values accepted by -include-unresolved.
+var unresolvedModes = []string{"", "note", "host"}
+
+// unresolvedNoteTitle is the title of the project note listing the DNS names
+// bbot found that did not resolve.
+const unresolvedNoteTitle = "drone-bbot: unresolved DNS names"
+
+// unresolvedHostIP is the IP of the placeholder host that holds unresolved DNS
+// names as hostnames with -include-unresolved host.
+const unresolvedHostIP = "0.0.0.0"
+
+// handleDNSNameUnresolved records the name of a DNS_NAME_UNRESOLVED event with
+// -include-unresolved, with the module that found it.
+func (im *importer) handleDNSNameUnresolved(entry map[string]interface{}) {
+	if im.opts.includeUnresolved == "" {
+		return
+	}
+	name, _ := entry["host"].(string)
+	if name == "" {
+		name, _ = entry["data"].(string)
+	}
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(sanitizeText(name)), "."))
+	if name == "" || strings.ContainsAny(name, " \t\n/") {
+		return
+	}
+	module, _ := entry["module"].(string)
+	if _, found := im.unresolved[name]; !found || im.unresolved[name] == "" {
+		im.unresolved[name] = module
+	}
+}
+
+// applyUnresolved adds the recorded unresolved DNS names to the project, as
+// lines of a project note with -include-unresolved note, or as hostnames of a
+// grey placeholder host tagged unresolved with -include-unresolved host. Names
+// that are a hostname of a host in the project are left out, they have
+// resolved in another scan.
+func (im *importer) applyUnresolved(existing []lair.Note) {
+	known := make(map[string]bool)
+	for _, host := range im.existingIPs {
+		for _, name := range host.Hostnames {
+			known[strings.ToLower(name)] = true
+		}
+	}
+	for _, host := range im.project.Hosts {
+		for _, name := range host.Hostnames {
+			known[strings.ToLower(name)] = true
+		}
+	}
+	names := []string{}
+	lines := []string{}
+	for name, module := range im.unresolved {
+		if known[name] {
+			continue
+		}
+		names = append(names, name)
+		if module != "" {
+			lines = append(lines, name+" (found by "+module+")")
+		} else {
+			lines = append(lines, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	switch im.opts.includeUnresolved {
+	case "note":
+		if note, ok := listNote(unresolvedNoteTitle, lines, existing); ok {
+			im.project.Notes = append(im.project.Notes, note)
+		}
+	case "host":
+		tags := append(append([]string{}, im.opts.hostTags...), im.opts.namespaceTag("unresolved"))
+		sort.Strings(names)
+		if im.updateHost(unresolvedHostIP, func(host *lair.Host) bool {
+			return mergeHost(host, names, tags)
+		}) {
+			return
+		}
+		im.project.Hosts = append(im.project.Hosts, lair.Host{
+			IPv4:           unresolvedHostIP,
+			Hostnames:      names,
+			Tags:           tags,
+			Status:         lair.StatusGrey,
+			LastModifiedBy: lastModifiedBy,
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// vhostNotePrefix starts the title of the note explaining how a virtual host
+// was found.
+const vhostNotePrefix = "drone-bbot: vhost "
+
+// handleVHost records the virtual host of a VHOST event, which bbot's vhost
+// module finds by brute forcing the Host header, for every IP of the web
+// server it answered on.
+func (im *importer) handleVHost(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	vhost, _ := data["vhost"].(string)
+	vhost = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(vhost)), ".")
+	if vhost == "" {
+		return
+	}
+	rawURL, _ := data["url"].(string)
+	for _, ip := range im.webIPs(entry) {
+		if im.vhosts[ip] == nil {
+			im.vhosts[ip] = make(map[string]string)
+		}
+		if _, found := im.vhosts[ip][vhost]; !found {
+			im.vhosts[ip][vhost] = rawURL
+		}
+	}
+}
+
+// applyVHosts adds the recorded virtual hosts to the hostnames of their hosts,
+// with a note explaining each with -vhost-notes. Hosts that are not in the
+// project are skipped.
+func (im *importer) applyVHosts() {
+	ips := []string{}
+	for ip := range im.vhosts {
+		ips = append(ips, ip)
+	}
+	sortIPs(ips)
+	for _, ip := range ips {
+		names := []string{}
+		for name := range im.vhosts[ip] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		im.updateHost(ip, func(host *lair.Host) bool {
+			changed := mergeHost(host, names, im.opts.hostTags)
+			if !im.opts.vhostNotes {
+				return changed
+			}
+			for _, name := range names {
+				title := vhostNotePrefix + name
+				if hasNote(host.Notes, title) {
+					continue
+				}
+				host.Notes = append(host.Notes, lair.Note{
+					Title:          title,
+					Content:        sanitizeText(fmt.Sprintf("%s was found by bbot's vhost module, which brute forces the Host header of %s. It may not resolve in DNS.", name, im.vhosts[ip][name])),
+					LastModifiedBy: lastModifiedBy,
+				})
+				changed = true
+			}
+			return changed
+		})
+	}
+}
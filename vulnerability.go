@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// vulnerabilityCVSS maps bbot severities to the CVSS score given to the issue,
+// chosen so that Lair's rating and -min-severity agree with bbot.
+var vulnerabilityCVSS = map[string]float64{
+	"CRITICAL": 9.5,
+	"HIGH":     7.5,
+	"MEDIUM":   5.0,
+	"LOW":      2.5,
+	"INFO":     0,
+}
+
+var (
+	cvePattern      = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	templatePattern = regexp.MustCompile(`(?i)template:\s*\[([^\]]+)\]`)
+	namePattern     = regexp.MustCompile(`(?i)name:\s*\[([^\]]+)\]`)
+)
+
+// vulnerabilityPluginID identifies the finding a VULNERABILITY event reports,
+// so that occurrences on different hosts merge into one issue. The nuclei
+// template is used when the description names one, otherwise a hash of the
+// description.
+func vulnerabilityPluginID(description string) string {
+	if m := templatePattern.FindStringSubmatch(description); m != nil {
+		return "vuln-" + strings.ToLower(strings.TrimSpace(m[1]))
+	}
+	return "vuln-" + shortHash(strings.ToLower(strings.TrimSpace(description)))
+}
+
+// shortHash returns a short hex digest of s for use in identifiers.
+func shortHash(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:6])
+}
+
+// vulnerabilityTitle returns a short title for the finding a VULNERABILITY
+// event reports.
+func vulnerabilityTitle(description string) string {
+	if m := namePattern.FindStringSubmatch(description); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	title := strings.TrimSpace(strings.SplitN(description, "\n", 2)[0])
+	if len(title) > 100 {
+		title = title[:97] + "..."
+	}
+	return title
+}
+
+// handleVulnerability records a VULNERABILITY event as an issue, with the
+// affected host and port, any CVEs it mentions, the raw event as a note and
+// with -provenance its discovery chain as evidence. Subdomain takeover
+// candidates are recorded for applyTakeovers instead.
+func (im *importer) handleVulnerability(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	description, _ := data["description"].(string)
+	if description == "" {
+		return
+	}
+	severity, _ := data["severity"].(string)
+	cvss, known := vulnerabilityCVSS[strings.ToUpper(severity)]
+	if !known {
+		cvss = vulnerabilityCVSS["INFO"]
+	}
+	if isTakeover(entry, description) {
+		im.recordTakeover(entry, description, cvss)
+		return
+	}
+	module, _ := entry["module"].(string)
+
+	issue := newIssue(vulnerabilityPluginID(description), sanitizeText(vulnerabilityTitle(description)), cvss, sanitizeText(description), "")
+	if module != "" {
+		issue.IdentifiedBy = append(issue.IdentifiedBy, lair.IdentifiedBy{Tool: "bbot " + module})
+	}
+	for _, cve := range cvePattern.FindAllString(description, -1) {
+		issue.CVEs, _ = appendUnique(issue.CVEs, strings.ToUpper(cve))
+	}
+
+	rawURL, _ := data["url"].(string)
+	port := 0
+	if rawURL != "" {
+		port = urlPort(rawURL)
+		issue.Evidence = rawURL
+	} else if host, _ := data["host"].(string); host != "" {
+		if _, p, err := net.SplitHostPort(host); err == nil {
+			port, _ = strconv.Atoi(p)
+		}
+		issue.Evidence = host
+	}
+	issue.Hosts = issueHosts(entry, port)
+	if chain := im.provenance(entry); chain != "" {
+		issue.Evidence = strings.TrimSpace(issue.Evidence + "\n" + chain)
+	}
+	issue.Evidence = im.limitEvidence(issue.Evidence)
+
+	raw, _ := json.MarshalIndent(entry, "", "  ")
+	title := "drone-bbot: bbot event "
+	if id, _ := entry["id"].(string); id != "" {
+		title += id
+	} else {
+		title += shortHash(string(raw))
+	}
+	issue.Notes = []lair.Note{{
+		Title:          title,
+		Content:        im.limitEvidence(sanitizeText(string(raw))),
+		LastModifiedBy: lastModifiedBy,
+	}}
+	im.addIssue(issue)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// wafNotePrefix starts the title of the note naming the WAF in front of a web
+// service.
+const wafNotePrefix = "drone-bbot: WAF "
+
+// wafDetection is a WAF bbot's wafw00f module found in front of a service.
+type wafDetection struct {
+	ip     string
+	port   int
+	scheme string
+	name   string
+	url    string
+}
+
+// wafTag returns the host tag for a WAF reported by wafw00f, such as
+// waf:cloudflare for "Cloudflare (Cloudflare Inc.)".
+func wafTag(name string) string {
+	if i := strings.Index(name, "("); i > 0 {
+		name = name[:i]
+	}
+	name = strings.Trim(cpeUnsafePattern.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-"), "-")
+	if name == "" {
+		return ""
+	}
+	return "waf:" + name
+}
+
+// handleWAF records the WAF of a WAF event for the web service it protects.
+func (im *importer) handleWAF(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	name, _ := data["waf"].(string)
+	rawURL, _ := data["url"].(string)
+	u, err := url.Parse(rawURL)
+	if name == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	port := urlPort(rawURL)
+	for _, ip := range im.webIPs(entry) {
+		im.wafs[fmt.Sprintf("%s:%d", ip, port)] = &wafDetection{ip: ip, port: port, scheme: u.Scheme, name: sanitizeText(name), url: rawURL}
+	}
+}
+
+// applyWAFs tags each host that has a recorded WAF and adds a note naming the
+// WAF to the protected service. Hosts that are not in the project are skipped.
+func (im *importer) applyWAFs() {
+	keys := []string{}
+	for key := range im.wafs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		waf := im.wafs[key]
+		im.updateHost(waf.ip, func(host *lair.Host) bool {
+			changed := false
+			if tag := wafTag(waf.name); tag != "" {
+				host.Tags, changed = appendUnique(host.Tags, im.opts.namespaceTag(tag))
+			}
+			service := ensureService(host, waf.port, "tcp", waf.scheme)
+			title := wafNotePrefix + waf.name
+			if !hasNote(service.Notes, title) {
+				service.Notes = append(service.Notes, lair.Note{
+					Title:          title,
+					Content:        sanitizeText(fmt.Sprintf("bbot's wafw00f module detected %s in front of %s. Requests may be filtered or blocked during testing.", waf.name, waf.url)),
+					LastModifiedBy: lastModifiedBy,
+				})
+				changed = true
+			}
+			return changed
+		})
+	}
+}
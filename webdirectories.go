@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+// webDirectory is a path bbot found on a web service.
+type webDirectory struct {
+	ip           string
+	port         int
+	path         string
+	responseCode string
+}
+
+// eventStatusCode returns the HTTP status bbot tagged an event with, such as
+// "200" for status-200, or an empty string.
+func eventStatusCode(entry map[string]interface{}) string {
+	tags, _ := entry["tags"].([]interface{})
+	for _, tag := range tags {
+		if s, ok := tag.(string); ok && strings.HasPrefix(s, "status-") {
+			return strings.TrimPrefix(s, "status-")
+		}
+	}
+	return ""
+}
+
+// recordWebDirectory remembers the path of a URL or URL_UNVERIFIED event, with
+// its HTTP status when bbot visited it, as a web directory of its host.
+func (im *importer) recordWebDirectory(entry map[string]interface{}, rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	port := urlPort(rawURL)
+	code := eventStatusCode(entry)
+	for _, ip := range im.webIPs(entry) {
+		key := fmt.Sprintf("%s:%d%s", ip, port, path)
+		if dir, found := im.webDirectories[key]; found && (code == "" || dir.responseCode != "") {
+			continue
+		}
+		im.webDirectories[key] = &webDirectory{ip: ip, port: port, path: sanitizeText(path), responseCode: code}
+	}
+}
+
+// applyWebDirectories adds the recorded web directories to their hosts. Lair
+// replaces the response code of a directory it already has, so the code of an
+// existing directory is kept when bbot did not report one. Hosts that are not
+// in the project are skipped.
+func (im *importer) applyWebDirectories() {
+	keys := []string{}
+	for key := range im.webDirectories {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		dir := im.webDirectories[key]
+		im.updateHost(dir.ip, func(host *lair.Host) bool {
+			for i, existing := range host.WebDirectories {
+				if existing.Path != dir.path || existing.Port != dir.port {
+					continue
+				}
+				if dir.responseCode == "" || existing.ResponseCode == dir.responseCode {
+					return false
+				}
+				host.WebDirectories[i].ResponseCode = dir.responseCode
+				host.WebDirectories[i].LastModifiedBy = lastModifiedBy
+				return true
+			}
+			host.WebDirectories = append(host.WebDirectories, lair.WebDirectory{
+				Path:           dir.path,
+				Port:           dir.port,
+				ResponseCode:   dir.responseCode,
+				LastModifiedBy: lastModifiedBy,
+			})
+			return true
+		})
+	}
+}
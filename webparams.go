@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+const webParametersNoteTitle = "drone-bbot: web parameters"
+
+// webParameters are the parameters bbot's paramminer modules found on a web
+// service, as note lines keyed by the line's first word.
+type webParameters struct {
+	ip     string
+	port   int
+	scheme string
+	lines  map[string]bool
+}
+
+// handleWebParameter records the parameter of a WEB_PARAMETER event for the web
+// service of its URL, as a line such as
+// "/login:POSTPARAM:user (paramminer_getparams)". The first word identifies
+// the parameter, so that a parameter found again is not listed twice.
+func (im *importer) handleWebParameter(entry map[string]interface{}) {
+	data, _ := entry["data"].(map[string]interface{})
+	rawURL, _ := data["url"].(string)
+	name, _ := data["name"].(string)
+	kind, _ := data["type"].(string)
+	u, err := url.Parse(rawURL)
+	if name == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	if kind == "" {
+		kind = "PARAMETER"
+	}
+	line := fmt.Sprintf("%s:%s:%s", path, strings.ToUpper(kind), strings.Join(strings.Fields(name), "_"))
+	if module, _ := entry["module"].(string); module != "" {
+		line += " (" + module + ")"
+	}
+	port := urlPort(rawURL)
+	for _, ip := range im.webIPs(entry) {
+		key := fmt.Sprintf("%s:%d", ip, port)
+		if im.webParameters[key] == nil {
+			im.webParameters[key] = &webParameters{ip: ip, port: port, scheme: u.Scheme, lines: make(map[string]bool)}
+		}
+		im.webParameters[key].lines[sanitizeText(line)] = true
+	}
+}
+
+// applyWebParameters adds a note listing the recorded parameters to each web
+// service, leaving out parameters already listed by earlier imports. Services
+// on IPs that are not hosts in the project are skipped.
+func (im *importer) applyWebParameters() {
+	keys := []string{}
+	for key := range im.webParameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		wp := im.webParameters[key]
+		lines := []string{}
+		for line := range wp.lines {
+			lines = append(lines, line)
+		}
+		im.updateHost(wp.ip, func(host *lair.Host) bool {
+			service := ensureService(host, wp.port, "tcp", wp.scheme)
+			note, ok := listNote(webParametersNoteTitle, lines, service.Notes)
+			if ok {
+				service.Notes = append(service.Notes, note)
+			}
+			return ok
+		})
+	}
+}
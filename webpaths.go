@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/lair-framework/go-lair"
+)
+
+const webPathsNoteTitle = "drone-bbot: robots.txt and sitemap paths"
+
+// webPathSource returns "robots.txt" or "sitemap" when a URL event was derived
+// from one of those files, or an empty string.
+func webPathSource(entry map[string]interface{}) string {
+	module, _ := entry["module"].(string)
+	context, _ := entry["discovery_context"].(string)
+	module = strings.ToLower(module)
+	context = strings.ToLower(context)
+	switch {
+	case module == "robots" || strings.Contains(context, "robots.txt"):
+		return "robots.txt"
+	case strings.Contains(module, "sitemap") || strings.Contains(context, "sitemap"):
+		return "sitemap"
+	}
+	return ""
+}
+
+// recordWebPath remembers the path of a URL found in robots.txt or a sitemap
+// so that it can be added to the notes of the web service it belongs to.
+func (im *importer) recordWebPath(entry map[string]interface{}, rawURL string) {
+	source := webPathSource(entry)
+	if source == "" {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return
+	}
+	port := urlPort(rawURL)
+	for _, ip := range im.webIPs(entry) {
+		key := fmt.Sprintf("%s:%d", ip, port)
+		if im.webPaths[key] == nil {
+			im.webPaths[key] = &webPaths{ip: ip, port: port, scheme: u.Scheme, paths: make(map[string]string)}
+		}
+		im.webPaths[key].paths[u.Path] = source
+	}
+}
+
+// webPaths are the robots.txt and sitemap paths found for a web service.
+type webPaths struct {
+	ip     string
+	port   int
+	scheme string
+	paths  map[string]string
+}
+
+// applyWebPaths adds a note listing the recorded paths to each web service.
+// Services on IPs that are not hosts in the project are skipped.
+func (im *importer) applyWebPaths() {
+	for _, wp := range im.webPaths {
+		paths := []string{}
+		for p := range wp.paths {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		var b strings.Builder
+		b.WriteString("Paths listed in robots.txt or sitemaps, useful for seeding content discovery:\n\n")
+		for _, p := range paths {
+			fmt.Fprintf(&b, "%s (%s)\n", p, wp.paths[p])
+		}
+		im.updateHost(wp.ip, func(host *lair.Host) bool {
+			service := ensureService(host, wp.port, "tcp", wp.scheme)
+			if hasNote(service.Notes, webPathsNoteTitle) {
+				return false
+			}
+			service.Notes = append(service.Notes, lair.Note{
+				Title:          webPathsNoteTitle,
+				Content:        sanitizeText(b.String()),
+				LastModifiedBy: lastModifiedBy,
+			})
+			return true
+		})
+	}
+}